@@ -0,0 +1,184 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	crypto "github.com/tendermint/go-crypto"
+
+	"github.com/cosmos/cosmos-sdk/client/context"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/wire"
+	"github.com/cosmos/cosmos-sdk/x/auth"
+
+	"github.com/kava-labs/kava/internal/x/paychan"
+	"github.com/kava-labs/kava/internal/x/paychan/interledger"
+)
+
+// rpcRequest is a minimal JSON-RPC 2.0 request envelope.
+type rpcRequest struct {
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+	ID     json.RawMessage `json:"id"`
+}
+
+type rpcResponse struct {
+	Result interface{}     `json:"result,omitempty"`
+	Error  string          `json:"error,omitempty"`
+	ID     json.RawMessage `json:"id"`
+}
+
+// rpcServer exposes a Connector's send_claim/verify_claim/settle operations
+// over JSON-RPC, for a connector plugin to call.
+type rpcServer struct {
+	conn *interledger.Connector
+
+	ctx         context.CoreContext
+	receiverKey crypto.PrivKey
+	cdc         *wire.Codec
+}
+
+func newRPCServer(conn *interledger.Connector, ctx context.CoreContext, receiverKey crypto.PrivKey, cdc *wire.Codec) *rpcServer {
+	return &rpcServer{conn: conn, ctx: ctx, receiverKey: receiverKey, cdc: cdc}
+}
+
+func (s *rpcServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req rpcRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeRPCError(w, req.ID, err.Error())
+		return
+	}
+
+	switch req.Method {
+	case "send_claim":
+		s.handleSendClaim(w, req)
+	case "verify_claim":
+		s.handleVerifyClaim(w, req)
+	case "settle":
+		s.handleSettle(w, req)
+	default:
+		writeRPCError(w, req.ID, "unknown method: "+req.Method)
+	}
+}
+
+type sendClaimParams struct {
+	AmountTotal sdk.Coins `json:"amount_total"`
+}
+
+type sendClaimResult struct {
+	Claim    paychan.SignedStreamClaim `json:"claim"`
+	Preimage []byte                    `json:"preimage"`
+}
+
+func (s *rpcServer) handleSendClaim(w http.ResponseWriter, req rpcRequest) {
+	var params sendClaimParams
+	if err := json.Unmarshal(req.Params, &params); err != nil {
+		writeRPCError(w, req.ID, err.Error())
+		return
+	}
+
+	claim, preimage, err := s.conn.SendClaim(params.AmountTotal)
+	if err != nil {
+		writeRPCError(w, req.ID, err.Error())
+		return
+	}
+	writeRPCResult(w, req.ID, sendClaimResult{Claim: claim, Preimage: preimage})
+}
+
+type verifyClaimParams struct {
+	Claim       paychan.SignedStreamClaim `json:"claim"`
+	Fulfillment []byte                    `json:"fulfillment"`
+}
+
+func (s *rpcServer) handleVerifyClaim(w http.ResponseWriter, req rpcRequest) {
+	var params verifyClaimParams
+	if err := json.Unmarshal(req.Params, &params); err != nil {
+		writeRPCError(w, req.ID, err.Error())
+		return
+	}
+	ok := s.conn.VerifyClaim(params.Claim, params.Fulfillment)
+	writeRPCResult(w, req.ID, ok)
+}
+
+type settleResult struct {
+	TxHash string `json:"tx_hash"`
+}
+
+// handleSettle posts the latest verified claim on-chain, the same as
+// Connector.Settle does for a caller embedding the keeper directly, except
+// here kvpaychand has to build, sign, and broadcast a MsgApplyStreamClaim
+// itself over the node it was started with.
+func (s *rpcServer) handleSettle(w http.ResponseWriter, req rpcRequest) {
+	if s.receiverKey == nil {
+		writeRPCError(w, req.ID, "this daemon has no -receiver-key; it can only verify claims, not settle them on-chain")
+		return
+	}
+
+	claim, ok := s.conn.LatestClaim()
+	if !ok {
+		writeRPCError(w, req.ID, "no verified claim to settle")
+		return
+	}
+
+	msg := paychan.MsgApplyStreamClaim{Claim: claim}
+	if err := msg.ValidateBasic(); err != nil {
+		writeRPCError(w, req.ID, err.Error())
+		return
+	}
+
+	receiver := sdk.Address(s.receiverKey.PubKey().Address())
+	acc, err := s.ctx.GetAccount(receiver)
+	if err != nil {
+		writeRPCError(w, req.ID, err.Error())
+		return
+	}
+
+	signMsg := auth.StdSignMsg{
+		ChainID:       s.ctx.ChainID,
+		AccountNumber: acc.GetAccountNumber(),
+		Sequence:      acc.GetSequence(),
+		Msgs:          []sdk.Msg{msg},
+		Fee:           auth.NewStdFee(200000, sdk.Coins{}),
+	}
+
+	sig, err := s.receiverKey.Sign(signMsg.Bytes())
+	if err != nil {
+		writeRPCError(w, req.ID, err.Error())
+		return
+	}
+	stdSig := auth.StdSignature{PubKey: s.receiverKey.PubKey(), Signature: sig}
+	tx := auth.NewStdTx(signMsg.Msgs, signMsg.Fee, []auth.StdSignature{stdSig})
+
+	txBytes, err := s.cdc.MarshalBinary(tx)
+	if err != nil {
+		writeRPCError(w, req.ID, err.Error())
+		return
+	}
+
+	res, err := s.ctx.BroadcastTx(txBytes)
+	if err != nil {
+		writeRPCError(w, req.ID, err.Error())
+		return
+	}
+	if res.CheckTx.Code != 0 {
+		writeRPCError(w, req.ID, fmt.Sprintf("settle rejected: %s", res.CheckTx.Log))
+		return
+	}
+	if res.DeliverTx.Code != 0 {
+		writeRPCError(w, req.ID, fmt.Sprintf("settle rejected: %s", res.DeliverTx.Log))
+		return
+	}
+
+	writeRPCResult(w, req.ID, settleResult{TxHash: fmt.Sprintf("%X", res.Hash)})
+}
+
+func writeRPCResult(w http.ResponseWriter, id json.RawMessage, result interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rpcResponse{Result: result, ID: id})
+}
+
+func writeRPCError(w http.ResponseWriter, id json.RawMessage, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rpcResponse{Error: msg, ID: id})
+}
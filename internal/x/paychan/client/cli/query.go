@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cosmos/cosmos-sdk/client/context"
+	"github.com/cosmos/cosmos-sdk/wire"
+
+	"github.com/kava-labs/kava/internal/x/paychan"
+)
+
+// GetPaychanCmd returns the command to look up a single channel by sender,
+// receiver, and id.
+func GetPaychanCmd(cdc *wire.Codec) *cobra.Command {
+	return &cobra.Command{
+		Use:   "paychan [sender] [receiver] [id]",
+		Short: "Query a payment channel",
+		Args:  cobra.ExactArgs(3),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.NewCoreContextFromViper()
+
+			res, err := ctx.QueryWithData(
+				fmt.Sprintf("custom/paychan/%s/%s/%s/%s", paychan.QueryPaychan, args[0], args[1], args[2]),
+				nil,
+			)
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(res))
+			return nil
+		},
+	}
+}
+
+// GetPaychansBySenderCmd returns the command to list every channel a sender
+// has opened.
+func GetPaychansBySenderCmd(cdc *wire.Codec) *cobra.Command {
+	return &cobra.Command{
+		Use:   "paychans-by-sender [sender]",
+		Short: "Query all payment channels opened by a sender",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.NewCoreContextFromViper()
+
+			res, err := ctx.QueryWithData(
+				fmt.Sprintf("custom/paychan/%s/%s", paychan.QueryPaychansBySender, args[0]),
+				nil,
+			)
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(res))
+			return nil
+		},
+	}
+}
+
+// GetPaychansByPairCmd returns the command to list every channel between a
+// given sender and receiver.
+func GetPaychansByPairCmd(cdc *wire.Codec) *cobra.Command {
+	return &cobra.Command{
+		Use:   "paychans-by-pair [sender] [receiver]",
+		Short: "Query all payment channels between a sender and receiver",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.NewCoreContextFromViper()
+
+			res, err := ctx.QueryWithData(
+				fmt.Sprintf("custom/paychan/%s/%s/%s", paychan.QueryPaychansByPair, args[0], args[1]),
+				nil,
+			)
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(res))
+			return nil
+		},
+	}
+}
@@ -0,0 +1,56 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/cosmos/cosmos-sdk/client/context"
+	"github.com/cosmos/cosmos-sdk/wire"
+
+	"github.com/kava-labs/kava/internal/x/paychan"
+)
+
+// RegisterRoutes wires up the paychan module's read-only REST endpoints.
+func RegisterRoutes(ctx context.CoreContext, r *mux.Router, cdc *wire.Codec) {
+	r.HandleFunc("/paychan/{sender}/{receiver}", paychansByPairHandler(ctx, cdc)).Methods("GET")
+	r.HandleFunc("/paychan/{sender}/{receiver}/{id}", paychanHandler(ctx, cdc)).Methods("GET")
+}
+
+func paychanHandler(ctx context.CoreContext, cdc *wire.Codec) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		res, err := ctx.QueryWithData(
+			"custom/paychan/"+paychan.QueryPaychan+"/"+vars["sender"]+"/"+vars["receiver"]+"/"+vars["id"],
+			nil,
+		)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(err.Error()))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(res)
+	}
+}
+
+func paychansByPairHandler(ctx context.CoreContext, cdc *wire.Codec) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		res, err := ctx.QueryWithData(
+			"custom/paychan/"+paychan.QueryPaychansByPair+"/"+vars["sender"]+"/"+vars["receiver"],
+			nil,
+		)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(err.Error()))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(res)
+	}
+}
@@ -0,0 +1,14 @@
+package paychan
+
+import (
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// EndBlocker settles every channel whose unilateral-close dispute window
+// ends this block, and cancels every HTLC whose timeout passes this block,
+// refunding it to its channel's balance. Called once per block from the
+// app's EndBlocker.
+func EndBlocker(ctx sdk.Context, k Keeper) sdk.Tags {
+	tags := k.settleMaturedChannels(ctx)
+	return tags.AppendTags(k.sweepExpiredHTLCs(ctx))
+}
@@ -0,0 +1,75 @@
+package paychan
+
+import (
+	"fmt"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// CodespacePaychan is the module's error codespace, so its errors are
+// distinguishable from every other module's in an ABCI log or query result.
+const CodespacePaychan sdk.CodespaceType = "paychan"
+
+// Paychan error codes, unique within CodespacePaychan.
+const (
+	CodeInvalidPaychanID             sdk.CodeType = 1
+	CodePaychanNotFound              sdk.CodeType = 2
+	CodePaychanClosing               sdk.CodeType = 3
+	CodeSequenceTooLow               sdk.CodeType = 4
+	CodeReceiverAmountExceedsBalance sdk.CodeType = 5
+	CodeHTLCPreimageMismatch         sdk.CodeType = 6
+	CodeHTLCExpired                  sdk.CodeType = 7
+	CodeUnauthorizedCloser           sdk.CodeType = 8
+)
+
+// ErrInvalidPaychanID is returned when a channel id is negative or otherwise
+// can't refer to a real channel.
+func ErrInvalidPaychanID(codespace sdk.CodespaceType, id int64) sdk.Error {
+	return sdk.NewError(codespace, CodeInvalidPaychanID, fmt.Sprintf("invalid paychan id: %d", id))
+}
+
+// ErrPaychanNotFound is returned when a (sender, receiver, id) triple doesn't
+// match any channel in the store.
+func ErrPaychanNotFound(codespace sdk.CodespaceType) sdk.Error {
+	return sdk.NewError(codespace, CodePaychanNotFound, "paychan not found")
+}
+
+// ErrPaychanClosing is returned when an operation that requires an open
+// channel (top-up, HTLC, stream claim, ...) is attempted on one already
+// mid unilateral-close.
+func ErrPaychanClosing(codespace sdk.CodespaceType) sdk.Error {
+	return sdk.NewError(codespace, CodePaychanClosing, "channel is closing")
+}
+
+// ErrSequenceTooLow is returned when a signed Update, BidirectionalUpdate, or
+// StreamClaim's sequence number doesn't exceed the one already acted on,
+// protecting against replay of a stale off-chain message.
+func ErrSequenceTooLow(codespace sdk.CodespaceType) sdk.Error {
+	return sdk.NewError(codespace, CodeSequenceTooLow, "sequence must be greater than the last one acted on")
+}
+
+// ErrReceiverAmountExceedsBalance is returned when a close, withdrawal,
+// stream claim, or HTLC would pay out more than remains locked in the
+// channel.
+func ErrReceiverAmountExceedsBalance(codespace sdk.CodespaceType) sdk.Error {
+	return sdk.NewError(codespace, CodeReceiverAmountExceedsBalance, "amount exceeds channel balance")
+}
+
+// ErrHTLCPreimageMismatch is returned when FulfillHTLC is given a preimage
+// that doesn't hash to the HTLC's hashlock.
+func ErrHTLCPreimageMismatch(codespace sdk.CodespaceType) sdk.Error {
+	return sdk.NewError(codespace, CodeHTLCPreimageMismatch, "preimage does not match hashlock")
+}
+
+// ErrHTLCExpired is returned when FulfillHTLC is attempted after the HTLC's
+// timeout height has already passed; it can only be cancelled at that point.
+func ErrHTLCExpired(codespace sdk.CodespaceType) sdk.Error {
+	return sdk.NewError(codespace, CodeHTLCExpired, "htlc has already timed out")
+}
+
+// ErrUnauthorizedCloser is returned when a message claiming to close,
+// withdraw from, or settle a channel carries a signature that doesn't check
+// out against the channel's recorded parties.
+func ErrUnauthorizedCloser(codespace sdk.CodespaceType) sdk.Error {
+	return sdk.NewError(codespace, CodeUnauthorizedCloser, "signature invalid")
+}
@@ -0,0 +1,138 @@
+package paychan
+
+import (
+	"fmt"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// NewHandler returns a handler for all paychan messages.
+func NewHandler(k Keeper) sdk.Handler {
+	return func(ctx sdk.Context, msg sdk.Msg) sdk.Result {
+		switch msg := msg.(type) {
+		case MsgCreatePaychan:
+			return handleMsgCreatePaychan(ctx, k, msg)
+		case MsgSubmitUpdate:
+			return handleMsgSubmitUpdate(ctx, k, msg)
+		case MsgClosePaychan:
+			return handleMsgClosePaychan(ctx, k, msg)
+		case MsgTopUp:
+			return handleMsgTopUp(ctx, k, msg)
+		case MsgWithdraw:
+			return handleMsgWithdraw(ctx, k, msg)
+		case MsgSenderTimeoutClose:
+			return handleMsgSenderTimeoutClose(ctx, k, msg)
+		case MsgCreateBidirectionalPaychan:
+			return handleMsgCreateBidirectionalPaychan(ctx, k, msg)
+		case MsgCloseBidirectionalPaychan:
+			return handleMsgCloseBidirectionalPaychan(ctx, k, msg)
+		case MsgApplyStreamClaim:
+			return handleMsgApplyStreamClaim(ctx, k, msg)
+		case MsgAddHTLC:
+			return handleMsgAddHTLC(ctx, k, msg)
+		case MsgFulfillHTLC:
+			return handleMsgFulfillHTLC(ctx, k, msg)
+		case MsgCancelHTLC:
+			return handleMsgCancelHTLC(ctx, k, msg)
+		default:
+			errMsg := fmt.Sprintf("unrecognized paychan message type: %T", msg)
+			return sdk.ErrUnknownRequest(errMsg).Result()
+		}
+	}
+}
+
+func handleMsgCreatePaychan(ctx sdk.Context, k Keeper, msg MsgCreatePaychan) sdk.Result {
+	tags, err := k.CreatePaychan(ctx, msg.Sender, msg.Receiver, msg.Amount, msg.ExpiryHeight)
+	if err != nil {
+		return err.Result()
+	}
+	return sdk.Result{Tags: tags}
+}
+
+func handleMsgTopUp(ctx sdk.Context, k Keeper, msg MsgTopUp) sdk.Result {
+	tags, err := k.TopUpPaychan(ctx, msg.Sender, msg.Receiver, msg.ID, msg.Amount)
+	if err != nil {
+		return err.Result()
+	}
+	return sdk.Result{Tags: tags}
+}
+
+func handleMsgWithdraw(ctx sdk.Context, k Keeper, msg MsgWithdraw) sdk.Result {
+	tags, err := k.WithdrawPaychan(ctx, msg.Update)
+	if err != nil {
+		return err.Result()
+	}
+	return sdk.Result{Tags: tags}
+}
+
+func handleMsgSenderTimeoutClose(ctx sdk.Context, k Keeper, msg MsgSenderTimeoutClose) sdk.Result {
+	tags, err := k.SenderTimeoutClose(ctx, msg.Sender, msg.Receiver, msg.ID)
+	if err != nil {
+		return err.Result()
+	}
+	return sdk.Result{Tags: tags}
+}
+
+func handleMsgCreateBidirectionalPaychan(ctx sdk.Context, k Keeper, msg MsgCreateBidirectionalPaychan) sdk.Result {
+	tags, err := k.CreateBidirectionalPaychan(ctx, msg.PartyA, msg.PartyB, msg.AmountA, msg.AmountB)
+	if err != nil {
+		return err.Result()
+	}
+	return sdk.Result{Tags: tags}
+}
+
+func handleMsgCloseBidirectionalPaychan(ctx sdk.Context, k Keeper, msg MsgCloseBidirectionalPaychan) sdk.Result {
+	tags, err := k.CloseBidirectionalPaychan(ctx, msg.Update)
+	if err != nil {
+		return err.Result()
+	}
+	return sdk.Result{Tags: tags}
+}
+
+func handleMsgSubmitUpdate(ctx sdk.Context, k Keeper, msg MsgSubmitUpdate) sdk.Result {
+	tags, err := k.SubmitUpdate(ctx, msg.Update)
+	if err != nil {
+		return err.Result()
+	}
+	return sdk.Result{Tags: tags}
+}
+
+func handleMsgClosePaychan(ctx sdk.Context, k Keeper, msg MsgClosePaychan) sdk.Result {
+	tags, err := k.ClosePaychan(ctx, msg.Sender, msg.Receiver, msg.ID, msg.ReceiverAmount)
+	if err != nil {
+		return err.Result()
+	}
+	return sdk.Result{Tags: tags}
+}
+
+func handleMsgApplyStreamClaim(ctx sdk.Context, k Keeper, msg MsgApplyStreamClaim) sdk.Result {
+	tags, err := k.ApplyStreamClaim(ctx, msg.Claim)
+	if err != nil {
+		return err.Result()
+	}
+	return sdk.Result{Tags: tags}
+}
+
+func handleMsgAddHTLC(ctx sdk.Context, k Keeper, msg MsgAddHTLC) sdk.Result {
+	tags, err := k.AddHTLC(ctx, msg.Sender, msg.Receiver, msg.ID, msg.Amount, msg.Hashlock, msg.TimeoutHeight, msg.Beneficiary)
+	if err != nil {
+		return err.Result()
+	}
+	return sdk.Result{Tags: tags}
+}
+
+func handleMsgFulfillHTLC(ctx sdk.Context, k Keeper, msg MsgFulfillHTLC) sdk.Result {
+	tags, err := k.FulfillHTLC(ctx, msg.Sender, msg.Receiver, msg.ID, msg.HTLCID, msg.Beneficiary, msg.Preimage)
+	if err != nil {
+		return err.Result()
+	}
+	return sdk.Result{Tags: tags}
+}
+
+func handleMsgCancelHTLC(ctx sdk.Context, k Keeper, msg MsgCancelHTLC) sdk.Result {
+	tags, err := k.CancelHTLC(ctx, msg.Sender, msg.Receiver, msg.ID, msg.HTLCID)
+	if err != nil {
+		return err.Result()
+	}
+	return sdk.Result{Tags: tags}
+}
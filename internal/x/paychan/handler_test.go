@@ -0,0 +1,71 @@
+package paychan
+
+import (
+	"testing"
+
+	crypto "github.com/tendermint/go-crypto"
+
+	sdkTypes "github.com/cosmos/cosmos-sdk/types"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_ClosePaychan_NotFoundReturnsPaychanErrorLog(t *testing.T) {
+	ctx, k := createTestInput(t)
+	handler := NewHandler(k)
+
+	sender := sdkTypes.Address(crypto.GenPrivKeyEd25519().PubKey().Address())
+	receiver := sdkTypes.Address(crypto.GenPrivKeyEd25519().PubKey().Address())
+
+	msg := MsgClosePaychan{Sender: sender, Receiver: receiver, ID: 1, ReceiverAmount: sdkTypes.Coins{{Denom: "KVA", Amount: 10}}}
+	res := handler(ctx, msg)
+
+	require.NotEqual(t, 0, res.Code)
+	require.Contains(t, res.Log, ErrPaychanNotFound(CodespacePaychan).Error())
+}
+
+func TestHandler_SubmitUpdate_BadSignatureReturnsPaychanErrorLog(t *testing.T) {
+	ctx, k := createTestInput(t)
+	handler := NewHandler(k)
+
+	senderKey := crypto.GenPrivKeyEd25519()
+	sender := sdkTypes.Address(senderKey.PubKey().Address())
+	receiver := sdkTypes.Address(crypto.GenPrivKeyEd25519().PubKey().Address())
+	amount := sdkTypes.Coins{{Denom: "KVA", Amount: 100}}
+
+	k.coinKeeper.AddCoins(ctx, sender, amount)
+	_, err := k.CreatePaychan(ctx, sender, receiver, amount, 0)
+	require.Nil(t, err)
+
+	su := signedUpdate(k.cdc, senderKey, receiver, 1, sdkTypes.Coins{{Denom: "KVA", Amount: 30}}, 1)
+	su.Sender = sdkTypes.Address(crypto.GenPrivKeyEd25519().PubKey().Address()) // no longer matches SenderPubKey
+	res := handler(ctx, MsgSubmitUpdate{Update: su})
+
+	require.NotEqual(t, 0, res.Code)
+	require.Contains(t, res.Log, ErrUnauthorizedCloser(CodespacePaychan).Error())
+}
+
+func TestHandler_TopUp_ClosingChannelReturnsPaychanErrorLog(t *testing.T) {
+	ctx, k := createTestInput(t)
+	// closingHeight 0 means "not closing", so the close must start at a real
+	// block height
+	ctx = ctx.WithBlockHeight(1)
+	handler := NewHandler(k)
+
+	senderKey := crypto.GenPrivKeyEd25519()
+	sender := sdkTypes.Address(senderKey.PubKey().Address())
+	receiver := sdkTypes.Address(crypto.GenPrivKeyEd25519().PubKey().Address())
+	amount := sdkTypes.Coins{{Denom: "KVA", Amount: 100}}
+
+	k.coinKeeper.AddCoins(ctx, sender, amount)
+	_, err := k.CreatePaychan(ctx, sender, receiver, amount, 0)
+	require.Nil(t, err)
+	su := signedUpdate(k.cdc, senderKey, receiver, 1, sdkTypes.Coins{{Denom: "KVA", Amount: 10}}, 1)
+	_, err = k.SubmitUpdate(ctx, su)
+	require.Nil(t, err)
+
+	res := handler(ctx, MsgTopUp{Sender: sender, Receiver: receiver, ID: 1, Amount: sdkTypes.Coins{{Denom: "KVA", Amount: 5}}})
+
+	require.NotEqual(t, 0, res.Code)
+	require.Contains(t, res.Log, ErrPaychanClosing(CodespacePaychan).Error())
+}
@@ -0,0 +1,210 @@
+package paychan
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// AddHTLC carves amount out of a channel's balance into a new pending HTLC,
+// payable to beneficiary if they reveal a preimage of hashlock before
+// timeoutHeight, or refundable into the channel's balance after.
+func (k Keeper) AddHTLC(ctx sdk.Context, sender sdk.Address, receiver sdk.Address, id int64, amount sdk.Coins, hashlock [32]byte, timeoutHeight int64, beneficiary sdk.Address) (sdk.Tags, sdk.Error) {
+	if !amount.IsValid() || !amount.IsPositive() {
+		return nil, sdk.ErrInvalidCoins(amount.String())
+	}
+	if timeoutHeight <= ctx.BlockHeight() {
+		return nil, sdk.ErrUnknownRequest("timeout height must be in the future")
+	}
+	pych, exists := k.GetPaychan(ctx, sender, receiver, id)
+	if !exists {
+		return nil, ErrPaychanNotFound(k.codespace)
+	}
+	if pych.IsClosing() {
+		return nil, ErrPaychanClosing(k.codespace)
+	}
+	if !pych.balance.Minus(amount).IsNotNegative() {
+		return nil, ErrReceiverAmountExceedsBalance(k.codespace)
+	}
+
+	htlcID := pych.nextHTLCID
+	pych.nextHTLCID++
+	htlc := PendingHTLC{
+		ID:            htlcID,
+		Amount:        amount,
+		Hashlock:      hashlock,
+		TimeoutHeight: timeoutHeight,
+		Beneficiary:   beneficiary,
+	}
+	pych.balance = pych.balance.Minus(amount)
+	pych.htlcs = append(pych.htlcs, htlc)
+	k.setPaychan(ctx, pych)
+
+	store := ctx.KVStore(k.storeKey)
+	pychKey := paychanKey(pych.sender, pych.receiver, pych.id)
+	store.Set(htlcTimeoutKey(timeoutHeight, pychKey, htlcID), []byte{})
+
+	tags := sdk.NewTags(
+		"action", []byte("add_htlc"),
+		"sender", pych.sender.Bytes(),
+		"receiver", pych.receiver.Bytes(),
+	)
+	return tags, nil
+}
+
+// FulfillHTLC pays out a pending HTLC's amount to its beneficiary, given the
+// preimage of its hashlock, provided it hasn't timed out yet.
+func (k Keeper) FulfillHTLC(ctx sdk.Context, sender sdk.Address, receiver sdk.Address, id int64, htlcID int64, beneficiary sdk.Address, preimage []byte) (sdk.Tags, sdk.Error) {
+	pych, exists := k.GetPaychan(ctx, sender, receiver, id)
+	if !exists {
+		return nil, ErrPaychanNotFound(k.codespace)
+	}
+	htlc, idx, found := findHTLC(pych.htlcs, htlcID)
+	if !found {
+		return nil, sdk.ErrUnknownRequest("htlc not found")
+	}
+	if !htlc.Beneficiary.Equals(beneficiary) {
+		return nil, sdk.ErrUnauthorized("only the htlc's beneficiary can fulfill it")
+	}
+	if ctx.BlockHeight() >= htlc.TimeoutHeight {
+		return nil, ErrHTLCExpired(k.codespace)
+	}
+	if sha256.Sum256(preimage) != htlc.Hashlock {
+		return nil, ErrHTLCPreimageMismatch(k.codespace)
+	}
+
+	pych.htlcs = append(pych.htlcs[:idx], pych.htlcs[idx+1:]...)
+	// withdrawn only tracks what the receiver has pulled out, so a later
+	// WithdrawPaychan/ApplyStreamClaim (whose cumulative amount is signed by
+	// the sender and only ever covers the receiver) can compute the right
+	// delta; an HTLC routed to some other beneficiary never touches it.
+	if htlc.Beneficiary.Equals(pych.receiver) {
+		pych.withdrawn = pych.withdrawn.Plus(htlc.Amount)
+	}
+	k.setPaychan(ctx, pych)
+
+	store := ctx.KVStore(k.storeKey)
+	pychKey := paychanKey(pych.sender, pych.receiver, pych.id)
+	store.Delete(htlcTimeoutKey(htlc.TimeoutHeight, pychKey, htlc.ID))
+
+	k.coinKeeper.AddCoins(ctx, htlc.Beneficiary, htlc.Amount)
+
+	tags := sdk.NewTags(
+		"action", []byte("fulfill_htlc"),
+		"sender", pych.sender.Bytes(),
+		"receiver", pych.receiver.Bytes(),
+		"beneficiary", htlc.Beneficiary.Bytes(),
+	)
+	return tags, nil
+}
+
+// CancelHTLC refunds a pending HTLC's amount back into the channel's balance
+// once its timeout has passed unfulfilled.
+func (k Keeper) CancelHTLC(ctx sdk.Context, sender sdk.Address, receiver sdk.Address, id int64, htlcID int64) (sdk.Tags, sdk.Error) {
+	pych, exists := k.GetPaychan(ctx, sender, receiver, id)
+	if !exists {
+		return nil, ErrPaychanNotFound(k.codespace)
+	}
+	htlc, idx, found := findHTLC(pych.htlcs, htlcID)
+	if !found {
+		return nil, sdk.ErrUnknownRequest("htlc not found")
+	}
+	if ctx.BlockHeight() < htlc.TimeoutHeight {
+		return nil, sdk.ErrUnauthorized("htlc has not timed out yet")
+	}
+
+	pych.htlcs = append(pych.htlcs[:idx], pych.htlcs[idx+1:]...)
+	pych.balance = pych.balance.Plus(htlc.Amount)
+	k.setPaychan(ctx, pych)
+
+	store := ctx.KVStore(k.storeKey)
+	pychKey := paychanKey(pych.sender, pych.receiver, pych.id)
+	store.Delete(htlcTimeoutKey(htlc.TimeoutHeight, pychKey, htlc.ID))
+
+	tags := sdk.NewTags(
+		"action", []byte("cancel_htlc"),
+		"sender", pych.sender.Bytes(),
+		"receiver", pych.receiver.Bytes(),
+	)
+	return tags, nil
+}
+
+// findHTLC looks up a channel's pending HTLC by id.
+func findHTLC(htlcs []PendingHTLC, htlcID int64) (PendingHTLC, int, bool) {
+	for i, h := range htlcs {
+		if h.ID == htlcID {
+			return h, i, true
+		}
+	}
+	return PendingHTLC{}, 0, false
+}
+
+// htlcTimeoutKey indexes a pending HTLC by the height its timeout passes,
+// mirroring closingQueueKey, so EndBlocker only does O(expiring) work per
+// block instead of scanning every channel's HTLCs. htlcID is appended after
+// pychKey (both fixed/determinate once split from the back) so two HTLCs on
+// the same channel timing out at the same height don't collide.
+func htlcTimeoutKey(timeoutHeight int64, pychKey []byte, htlcID int64) []byte {
+	key := []byte{0x06}
+	heightBytes := make([]byte, 8)
+	binary.BigEndian.PutUint64(heightBytes, uint64(timeoutHeight))
+	key = append(key, heightBytes...)
+	key = append(key, pychKey...)
+	key = append(key, idBytes(htlcID)...)
+	return key
+}
+
+// sweepExpiredHTLCs cancels every HTLC whose timeout passes at the current
+// block height, refunding its amount back into the channel's balance. It's
+// the HTLC half of EndBlocker.
+func (k Keeper) sweepExpiredHTLCs(ctx sdk.Context) sdk.Tags {
+	store := ctx.KVStore(k.storeKey)
+
+	heightBytes := make([]byte, 8)
+	binary.BigEndian.PutUint64(heightBytes, uint64(ctx.BlockHeight()))
+	prefix := append([]byte{0x06}, heightBytes...)
+
+	iter := store.Iterator(prefix, sdk.PrefixEndBytes(prefix))
+	defer iter.Close()
+
+	var queueKeys [][]byte
+	var pychKeys [][]byte
+	var htlcIDs []int64
+	for ; iter.Valid(); iter.Next() {
+		queueKeys = append(queueKeys, iter.Key())
+		rest := iter.Key()[len(prefix):]
+		htlcIDBytes := rest[len(rest)-8:]
+		pychKeys = append(pychKeys, rest[:len(rest)-8])
+		htlcIDs = append(htlcIDs, int64(binary.BigEndian.Uint64(htlcIDBytes)))
+	}
+
+	tags := sdk.NewTags()
+	for i, pychKey := range pychKeys {
+		bz := store.Get(pychKey)
+		if bz == nil { // channel already gone
+			store.Delete(queueKeys[i])
+			continue
+		}
+		var pych Paychan
+		k.cdc.MustUnmarshalBinary(bz, &pych)
+
+		htlc, idx, found := findHTLC(pych.htlcs, htlcIDs[i])
+		if !found { // already fulfilled or cancelled
+			store.Delete(queueKeys[i])
+			continue
+		}
+
+		pych.htlcs = append(pych.htlcs[:idx], pych.htlcs[idx+1:]...)
+		pych.balance = pych.balance.Plus(htlc.Amount)
+		k.setPaychan(ctx, pych)
+		store.Delete(queueKeys[i])
+
+		tags = tags.AppendTags(sdk.NewTags(
+			"action", []byte("cancel_htlc"),
+			"sender", pych.sender.Bytes(),
+			"receiver", pych.receiver.Bytes(),
+		))
+	}
+	return tags
+}
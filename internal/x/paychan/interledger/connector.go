@@ -0,0 +1,109 @@
+// Package interledger lets a kava paychan act as the settlement layer for an
+// Interledger STREAM connector: one side of a channel signs a stream of
+// hash-locked StreamClaims to pay for ILP packets as they arrive, while the
+// other side verifies and holds them, posting only the latest one on-chain
+// whenever it decides to settle.
+package interledger
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/wire"
+	crypto "github.com/tendermint/go-crypto"
+
+	"github.com/kava-labs/kava/internal/x/paychan"
+)
+
+// Connector is the off-chain state a kvpaychand daemon keeps for one paychan
+// being used as an ILP settlement channel. senderKey is only needed on the
+// side that sends claims; a receive-only Connector may leave it nil.
+type Connector struct {
+	cdc       *wire.Codec
+	senderKey crypto.PrivKey
+
+	sender   sdk.Address
+	receiver sdk.Address
+	id       int64
+
+	sequence int64
+	latest   *paychan.SignedStreamClaim
+}
+
+// NewConnector returns a Connector tracking one channel.
+func NewConnector(cdc *wire.Codec, senderKey crypto.PrivKey, sender, receiver sdk.Address, id int64) *Connector {
+	return &Connector{cdc: cdc, senderKey: senderKey, sender: sender, receiver: receiver, id: id}
+}
+
+// SendClaim signs and returns a new claim authorizing amountTotal (the
+// cumulative amount moved through the channel so far, not just this packet)
+// hash-locked to a freshly generated preimage. It returns the preimage
+// separately, so the caller can hand the claim to its counterparty before
+// releasing what it pays for.
+func (c *Connector) SendClaim(amountTotal sdk.Coins) (paychan.SignedStreamClaim, []byte, error) {
+	if c.senderKey == nil {
+		return paychan.SignedStreamClaim{}, nil, fmt.Errorf("connector has no sender key; it can only verify claims")
+	}
+
+	preimage := make([]byte, 32)
+	if _, err := rand.Read(preimage); err != nil {
+		return paychan.SignedStreamClaim{}, nil, err
+	}
+	condition := sha256.Sum256(preimage)
+
+	c.sequence++
+	claim := paychan.StreamClaim{
+		Sender:      c.sender,
+		Receiver:    c.receiver,
+		ID:          c.id,
+		AmountDelta: amountTotal,
+		Sequence:    c.sequence,
+		Condition:   condition,
+	}
+	signed, err := paychan.SignStreamClaim(c.cdc, c.senderKey, claim)
+	if err != nil {
+		c.sequence--
+		return paychan.SignedStreamClaim{}, nil, err
+	}
+	return signed, preimage, nil
+}
+
+// VerifyClaim checks that claim's signature and hashlock are self-consistent
+// and that it supersedes whatever claim this Connector is currently holding.
+// If so, it becomes the new latest claim to settle.
+func (c *Connector) VerifyClaim(claim paychan.SignedStreamClaim, fulfillment []byte) bool {
+	claim.Fulfillment = fulfillment
+	if !claim.Verify(c.cdc) {
+		return false
+	}
+	if sha256.Sum256(fulfillment) != claim.Condition {
+		return false
+	}
+	if c.latest != nil && claim.Sequence <= c.latest.Sequence {
+		return false
+	}
+	c.latest = &claim
+	return true
+}
+
+// LatestClaim returns the most recent claim VerifyClaim has accepted, for
+// callers (e.g. kvpaychand's settle RPC) that need to broadcast it
+// themselves instead of applying it against a local Keeper.
+func (c *Connector) LatestClaim() (paychan.SignedStreamClaim, bool) {
+	if c.latest == nil {
+		return paychan.SignedStreamClaim{}, false
+	}
+	return *c.latest, true
+}
+
+// Settle posts the single latest verified claim on-chain, crediting the
+// receiver for every packet paid for off-chain since the channel's last
+// on-chain settlement, in one write.
+func (c *Connector) Settle(ctx sdk.Context, k paychan.Keeper) (sdk.Tags, sdk.Error) {
+	if c.latest == nil {
+		return nil, sdk.ErrUnknownRequest("no claim to settle")
+	}
+	return k.ApplyStreamClaim(ctx, *c.latest)
+}
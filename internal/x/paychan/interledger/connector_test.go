@@ -0,0 +1,96 @@
+package interledger
+
+import (
+	"testing"
+
+	abci "github.com/tendermint/abci/types"
+	crypto "github.com/tendermint/go-crypto"
+	dbm "github.com/tendermint/tmlibs/db"
+	"github.com/tendermint/tmlibs/log"
+
+	sdkStore "github.com/cosmos/cosmos-sdk/store"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/wire"
+	"github.com/cosmos/cosmos-sdk/x/auth"
+	"github.com/cosmos/cosmos-sdk/x/bank"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kava-labs/kava/internal/x/paychan"
+)
+
+func createTestInput(t *testing.T) (sdk.Context, paychan.Keeper, bank.Keeper) {
+	db := dbm.NewMemDB()
+	authKey := sdk.NewKVStoreKey("auth")
+	paychanKey := sdk.NewKVStoreKey("paychan")
+
+	ms := sdkStore.NewCommitMultiStore(db)
+	ms.MountStoreWithDB(authKey, sdk.StoreTypeIAVL, db)
+	ms.MountStoreWithDB(paychanKey, sdk.StoreTypeIAVL, db)
+	require.Nil(t, ms.LoadLatestVersion())
+
+	ctx := sdk.NewContext(ms, abci.Header{}, false, log.NewNopLogger())
+	cdc := wire.NewCodec()
+
+	accountMapper := auth.NewAccountMapper(cdc, authKey, auth.ProtoBaseAccount)
+	ck := bank.NewKeeper(accountMapper)
+	keeper := paychan.NewKeeper(cdc, paychanKey, ck, paychan.DefaultDisputeBlocks, paychan.CodespacePaychan)
+
+	return ctx, keeper, ck
+}
+
+// TestConnector_TenThousandClaimsOnlyFinalSettlesOnChain exercises a
+// connector exchanging many ILP-packet-sized claims entirely off-chain,
+// checking that only the final, most-inclusive one is ever posted on-chain.
+func TestConnector_TenThousandClaimsOnlyFinalSettlesOnChain(t *testing.T) {
+	ctx, k, ck := createTestInput(t)
+	cdc := wire.NewCodec()
+
+	senderKey := crypto.GenPrivKeyEd25519()
+	sender := sdk.Address(senderKey.PubKey().Address())
+	receiver := sdk.Address(crypto.GenPrivKeyEd25519().PubKey().Address())
+	amount := sdk.Coins{{Denom: "KVA", Amount: 10000}}
+
+	ck.AddCoins(ctx, sender, amount)
+	_, err := k.CreatePaychan(ctx, sender, receiver, amount, 0)
+	require.Nil(t, err)
+
+	senderSide := NewConnector(cdc, senderKey, sender, receiver, 1)
+	receiverSide := NewConnector(cdc, nil, sender, receiver, 1)
+
+	for i := int64(1); i <= 10000; i++ {
+		claim, preimage, err := senderSide.SendClaim(sdk.Coins{{Denom: "KVA", Amount: i}})
+		require.Nil(t, err)
+		require.True(t, receiverSide.VerifyClaim(claim, preimage))
+	}
+
+	_, sdkErr := receiverSide.Settle(ctx, k)
+	require.Nil(t, sdkErr)
+
+	require.Equal(t, sdk.Coins{{Denom: "KVA", Amount: 10000}}, ck.GetCoins(ctx, receiver))
+
+	pych, found := k.GetPaychan(ctx, sender, receiver, 1)
+	require.True(t, found)
+	require.Equal(t, int64(10000), pych.Sequence())
+}
+
+func TestConnector_StaleClaimRejected(t *testing.T) {
+	cdc := wire.NewCodec()
+	senderKey := crypto.GenPrivKeyEd25519()
+	sender := sdk.Address(senderKey.PubKey().Address())
+	receiver := sdk.Address(crypto.GenPrivKeyEd25519().PubKey().Address())
+
+	senderSide := NewConnector(cdc, senderKey, sender, receiver, 1)
+	receiverSide := NewConnector(cdc, nil, sender, receiver, 1)
+
+	first, firstPreimage, err := senderSide.SendClaim(sdk.Coins{{Denom: "KVA", Amount: 5}})
+	require.Nil(t, err)
+	require.True(t, receiverSide.VerifyClaim(first, firstPreimage))
+
+	second, secondPreimage, err := senderSide.SendClaim(sdk.Coins{{Denom: "KVA", Amount: 10}})
+	require.Nil(t, err)
+	require.True(t, receiverSide.VerifyClaim(second, secondPreimage))
+
+	// a replayed or stale claim must not roll the connector back
+	require.False(t, receiverSide.VerifyClaim(first, firstPreimage))
+}
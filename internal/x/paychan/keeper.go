@@ -1,13 +1,19 @@
 package paychan
 
 import (
-	"strconv"
+	"crypto/sha256"
+	"encoding/binary"
 
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/cosmos/cosmos-sdk/wire"
 	"github.com/cosmos/cosmos-sdk/x/bank"
 )
 
+// DefaultDisputeBlocks is the dispute window used if the app doesn't pick its
+// own. A unilateral close started via SubmitUpdate can be challenged with a
+// higher-sequence update for this many blocks before it settles.
+const DefaultDisputeBlocks = int64(1000)
+
 // keeper of the paychan store
 // Handles validation internally. Does not rely on calling code to do validation.
 // Aim to keep public methids safe, private ones not necessaily.
@@ -16,18 +22,22 @@ type Keeper struct {
 	cdc        *wire.Codec // needed to serialize objects before putting them in the store
 	coinKeeper bank.Keeper
 
-	// codespace
-	//codespace sdk.CodespaceType // ??
+	// disputeBlocks is how many blocks a unilateral close waits before
+	// EndBlocker settles it, during which either party can submit a
+	// higher-sequence update.
+	disputeBlocks int64
+
+	codespace sdk.CodespaceType
 }
 
 // Called when creating new app.
-//func NewKeeper(cdc *wire.Codec, key sdk.StoreKey, ck bank.Keeper, codespace sdk.CodespaceType) Keeper {
-func NewKeeper(cdc *wire.Codec, key sdk.StoreKey, ck bank.Keeper) Keeper {
+func NewKeeper(cdc *wire.Codec, key sdk.StoreKey, ck bank.Keeper, disputeBlocks int64, codespace sdk.CodespaceType) Keeper {
 	keeper := Keeper{
-		storeKey:   key,
-		cdc:        cdc,
-		coinKeeper: ck,
-		//codespace:  codespace,
+		storeKey:      key,
+		cdc:           cdc,
+		coinKeeper:    ck,
+		disputeBlocks: disputeBlocks,
+		codespace:     codespace,
 	}
 	return keeper
 }
@@ -61,8 +71,11 @@ func (k Keeper) setPaychan(ctx sdk.Context, pych Paychan) {
 	store.Set(pychKey, bz) // panics if something goes wrong
 }
 
-// Create a new payment channel and lock up sender funds.
-func (k Keeper) CreatePaychan(ctx sdk.Context, sender sdk.Address, receiver sdk.Address, amount sdk.Coins) (sdk.Tags, sdk.Error) {
+// Create a new payment channel and lock up sender funds. expiryHeight, if
+// non-zero, lets the sender reclaim the channel via SenderTimeoutClose once
+// the chain passes that height, protecting against a receiver who never
+// shows up to close the channel.
+func (k Keeper) CreatePaychan(ctx sdk.Context, sender sdk.Address, receiver sdk.Address, amount sdk.Coins, expiryHeight int64) (sdk.Tags, sdk.Error) {
 	// TODO move validation somewhere nicer
 	// args present
 	if len(sender) == 0 {
@@ -92,8 +105,9 @@ func (k Keeper) CreatePaychan(ctx sdk.Context, sender sdk.Address, receiver sdk.
 	// sender has enough coins - done in Subtract method
 	// TODO check if sender and receiver different?
 
-	// Calculate next id (num existing paychans plus 1)
-	id := int64(len(k.GetPaychans(sender, receiver)) + 1) // TODO check for overflow?
+	// Get next id for this (sender, receiver) pair from the dedicated counter,
+	// so that a deleted channel's id is never handed out again.
+	id := k.getAndIncrementNextPaychanID(ctx, sender, receiver)
 	// subtract coins from sender
 	_, tags, err := k.coinKeeper.SubtractCoins(ctx, sender, amount)
 	if err != nil {
@@ -101,10 +115,11 @@ func (k Keeper) CreatePaychan(ctx sdk.Context, sender sdk.Address, receiver sdk.
 	}
 	// create new Paychan struct
 	pych := Paychan{
-		sender:   sender,
-		receiver: receiver,
-		id:       id,
-		balance:  amount,
+		sender:       sender,
+		receiver:     receiver,
+		id:           id,
+		balance:      amount,
+		expiryHeight: expiryHeight,
 	}
 	// save to db
 	k.setPaychan(ctx, pych)
@@ -127,7 +142,7 @@ func (k Keeper) ClosePaychan(ctx sdk.Context, sender sdk.Address, receiver sdk.A
 	}
 	// check id ≥ 0
 	if id < 0 {
-		return nil, sdk.ErrInvalidAddress(strconv.Itoa(int(id))) // TODO implement custom errors
+		return nil, ErrInvalidPaychanID(k.codespace, id)
 	}
 
 	// Check if coins are sorted, non zero, non negative
@@ -142,13 +157,19 @@ func (k Keeper) ClosePaychan(ctx sdk.Context, sender sdk.Address, receiver sdk.A
 
 	pych, exists := k.GetPaychan(ctx, sender, receiver, id)
 	if !exists {
-		return nil, sdk.ErrUnknownAddress("paychan not found") // TODO implement custom errors
+		return nil, ErrPaychanNotFound(k.codespace)
+	}
+	// Closing while HTLCs are live would need to decide who gets their
+	// locked amount; simplest to just make the caller resolve them first by
+	// fulfilling or cancelling (once timed out) each one.
+	if len(pych.htlcs) > 0 {
+		return nil, sdk.ErrUnauthorized("cannot close a channel with pending htlcs")
 	}
 	// compute coin distribution
 	senderAmount := pych.balance.Minus(receiverAmount) // Minus sdk.Coins method
 	// check that receiverAmt not greater than paychan balance
 	if !senderAmount.IsNotNegative() {
-		return nil, sdk.ErrInsufficientFunds(pych.balance.String())
+		return nil, ErrReceiverAmountExceedsBalance(k.codespace)
 	}
 	// add coins to sender
 	// creating account if it doesn't exist
@@ -159,6 +180,11 @@ func (k Keeper) ClosePaychan(ctx sdk.Context, sender sdk.Address, receiver sdk.A
 	// delete paychan from db
 	pychKey := paychanKey(pych.sender, pych.receiver, pych.id)
 	store.Delete(pychKey)
+	// cooperative close settles immediately, so drop any pending unilateral
+	// close this channel might have had queued
+	if pych.IsClosing() {
+		store.Delete(closingQueueKey(pych.closingHeight+k.disputeBlocks, pychKey))
+	}
 
 	// TODO create tags
 	//sdk.NewTags(
@@ -170,23 +196,472 @@ func (k Keeper) ClosePaychan(ctx sdk.Context, sender sdk.Address, receiver sdk.A
 	return tags, nil
 }
 
-// Creates a key to reference a paychan in the blockchain store.
+// addressPairKey length-prefixes each address so that, unlike plain
+// concatenation, two different (sender, receiver) pairs can never produce
+// overlapping byte strings (e.g. sender="ab",receiver="c" colliding with
+// sender="a",receiver="bc").
+func addressPairKey(sender sdk.Address, receiver sdk.Address) []byte {
+	key := []byte{byte(len(sender))}
+	key = append(key, sender.Bytes()...)
+	key = append(key, byte(len(receiver)))
+	key = append(key, receiver.Bytes()...)
+	return key
+}
+
+// idBytes big-endian encodes an id so that store iteration visits channels
+// of a pair in ascending id order.
+func idBytes(id int64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, uint64(id))
+	return b
+}
+
+// Creates a key to reference a paychan in the blockchain store:
+// 0x01 || len(sender) || sender || len(receiver) || receiver || bigEndian(id)
 func paychanKey(sender sdk.Address, receiver sdk.Address, id int64) []byte {
+	return append(paychanSubspaceKey(sender, receiver), idBytes(id)...)
+}
+
+// paychanSubspaceKey is the prefix shared by every channel between sender and
+// receiver, letting GetPaychans iterate just that pair.
+func paychanSubspaceKey(sender sdk.Address, receiver sdk.Address) []byte {
+	return append([]byte{0x01}, addressPairKey(sender, receiver)...)
+}
+
+// senderSubspaceKey is the prefix shared by every channel a sender has opened
+// with anyone, letting GetAllPaychansFrom iterate just that sender.
+func senderSubspaceKey(sender sdk.Address) []byte {
+	key := []byte{0x01, byte(len(sender))}
+	return append(key, sender.Bytes()...)
+}
+
+// nextIDKey stores the next id to hand out for a given (sender, receiver)
+// pair, so that deleting a channel never causes its id to be reused.
+func nextIDKey(sender sdk.Address, receiver sdk.Address) []byte {
+	return append([]byte{0x04}, addressPairKey(sender, receiver)...)
+}
+
+// getAndIncrementNextPaychanID returns the next unused id for a (sender,
+// receiver) pair and advances the counter past it.
+func (k Keeper) getAndIncrementNextPaychanID(ctx sdk.Context, sender sdk.Address, receiver sdk.Address) int64 {
+	store := ctx.KVStore(k.storeKey)
+	key := nextIDKey(sender, receiver)
+
+	id := int64(1)
+	if bz := store.Get(key); bz != nil {
+		id = int64(binary.BigEndian.Uint64(bz))
+	}
+	store.Set(key, idBytes(id+1))
+	return id
+}
 
-	//sdk.Address is just a slice of bytes under a different name
-	//convert id to string then to byte slice
-	idAsBytes := []byte(strconv.Itoa(int(id)))
-	// concat sender and receiver and integer ID
-	key := append(sender.Bytes(), receiver.Bytes()...)
-	key = append(key, idAsBytes...)
+// Creates a key to reference a bidirectional paychan in the blockchain store.
+func bidirectionalPaychanKey(partyA sdk.Address, partyB sdk.Address, id int64) []byte {
+	key := []byte{0x03}
+	key = append(key, addressPairKey(partyA, partyB)...)
+	key = append(key, idBytes(id)...)
 	return key
 }
 
-// Get all paychans between a given sender and receiver.
-func (k Keeper) GetPaychans(sender sdk.Address, receiver sdk.Address) []Paychan {
+// bidirectionalNextIDKey mirrors nextIDKey, for bidirectional channels.
+func bidirectionalNextIDKey(partyA sdk.Address, partyB sdk.Address) []byte {
+	return append([]byte{0x05}, addressPairKey(partyA, partyB)...)
+}
+
+func (k Keeper) getAndIncrementNextBidirectionalPaychanID(ctx sdk.Context, partyA sdk.Address, partyB sdk.Address) int64 {
+	store := ctx.KVStore(k.storeKey)
+	key := bidirectionalNextIDKey(partyA, partyB)
+
+	id := int64(1)
+	if bz := store.Get(key); bz != nil {
+		id = int64(binary.BigEndian.Uint64(bz))
+	}
+	store.Set(key, idBytes(id+1))
+	return id
+}
+
+// GetPaychans returns every channel between a given sender and receiver.
+func (k Keeper) GetPaychans(ctx sdk.Context, sender sdk.Address, receiver sdk.Address) []Paychan {
+	prefix := paychanSubspaceKey(sender, receiver)
+	return k.iteratePaychans(ctx, prefix)
+}
+
+// GetAllPaychansFrom returns every channel a given sender has opened, with
+// any receiver.
+func (k Keeper) GetAllPaychansFrom(ctx sdk.Context, sender sdk.Address) []Paychan {
+	prefix := senderSubspaceKey(sender)
+	return k.iteratePaychans(ctx, prefix)
+}
+
+// GetAllPaychans returns every channel in the store.
+func (k Keeper) GetAllPaychans(ctx sdk.Context) []Paychan {
+	return k.iteratePaychans(ctx, []byte{0x01})
+}
+
+// iteratePaychans collects every channel whose key starts with prefix.
+func (k Keeper) iteratePaychans(ctx sdk.Context, prefix []byte) []Paychan {
 	var paychans []Paychan
-	// TODO Implement this
+	store := ctx.KVStore(k.storeKey)
+	iter := store.Iterator(prefix, sdk.PrefixEndBytes(prefix))
+	defer iter.Close()
+	for ; iter.Valid(); iter.Next() {
+		var pych Paychan
+		k.cdc.MustUnmarshalBinary(iter.Value(), &pych)
+		paychans = append(paychans, pych)
+	}
 	return paychans
 }
 
-// maybe getAllPaychans(sender sdk.address) []Paychan
+// SubmitUpdate starts, or advances, a unilateral close of a channel. The
+// receiver normally calls this with the highest-sequence Update the sender
+// has signed for them; either party may call it again during the dispute
+// window with an even higher sequence to replace what's stored.
+func (k Keeper) SubmitUpdate(ctx sdk.Context, su SignedUpdate) (sdk.Tags, sdk.Error) {
+	if !su.Verify(k.cdc) {
+		return nil, ErrUnauthorizedCloser(k.codespace)
+	}
+	pych, exists := k.GetPaychan(ctx, su.Sender, su.Receiver, su.ID)
+	if !exists {
+		return nil, ErrPaychanNotFound(k.codespace)
+	}
+	if su.Sequence <= pych.sequence {
+		return nil, ErrSequenceTooLow(k.codespace)
+	}
+	// Maturing a unilateral close with live HTLCs would strand their carved-
+	// out amounts, since settleMaturedChannels only splits pych.balance;
+	// make the caller resolve them (fulfill or cancel once timed out) first,
+	// same as ClosePaychan.
+	if len(pych.htlcs) > 0 {
+		return nil, sdk.ErrUnauthorized("cannot close a channel with pending htlcs")
+	}
+	// ReceiverAmount is cumulative since the channel opened, so what has
+	// already been withdrawn counts towards it and has left balance.
+	if !su.ReceiverAmount.IsValid() || !su.ReceiverAmount.IsGTE(pych.withdrawn) {
+		return nil, sdk.ErrInvalidCoins(su.ReceiverAmount.String())
+	}
+	if !pych.balance.Minus(su.ReceiverAmount.Minus(pych.withdrawn)).IsNotNegative() {
+		return nil, ErrReceiverAmountExceedsBalance(k.codespace)
+	}
+
+	store := ctx.KVStore(k.storeKey)
+	pychKey := paychanKey(pych.sender, pych.receiver, pych.id)
+
+	// drop the previous queue entry, if this update is replacing one
+	if pych.IsClosing() {
+		store.Delete(closingQueueKey(pych.closingHeight+k.disputeBlocks, pychKey))
+	}
+
+	pych.closingHeight = ctx.BlockHeight()
+	pych.sequence = su.Sequence
+	pych.closingReceiverAmount = su.ReceiverAmount
+	k.setPaychan(ctx, pych)
+
+	store.Set(closingQueueKey(pych.closingHeight+k.disputeBlocks, pychKey), []byte{})
+
+	tags := sdk.NewTags(
+		"action", []byte("submit_update"),
+		"sender", pych.sender.Bytes(),
+		"receiver", pych.receiver.Bytes(),
+	)
+	return tags, nil
+}
+
+// closingQueueKey indexes a channel, mid unilateral-close, by the height at
+// which its dispute window ends. This lets EndBlocker find matured channels
+// without scanning every channel in the store every block.
+func closingQueueKey(maturityHeight int64, pychKey []byte) []byte {
+	key := []byte{0x02}
+	heightBytes := make([]byte, 8)
+	binary.BigEndian.PutUint64(heightBytes, uint64(maturityHeight))
+	key = append(key, heightBytes...)
+	key = append(key, pychKey...)
+	return key
+}
+
+// settleMaturedChannels pays out and removes every channel whose dispute
+// window ends at the current block height. It's the guts of EndBlocker.
+func (k Keeper) settleMaturedChannels(ctx sdk.Context) sdk.Tags {
+	store := ctx.KVStore(k.storeKey)
+
+	heightBytes := make([]byte, 8)
+	binary.BigEndian.PutUint64(heightBytes, uint64(ctx.BlockHeight()))
+	prefix := append([]byte{0x02}, heightBytes...)
+
+	iter := store.Iterator(prefix, sdk.PrefixEndBytes(prefix))
+	defer iter.Close()
+
+	var queueKeys, pychKeys [][]byte
+	for ; iter.Valid(); iter.Next() {
+		queueKeys = append(queueKeys, iter.Key())
+		pychKeys = append(pychKeys, iter.Key()[len(prefix):])
+	}
+
+	tags := sdk.NewTags()
+	for i, pychKey := range pychKeys {
+		bz := store.Get(pychKey)
+		if bz == nil { // channel already gone (e.g. cooperatively closed)
+			store.Delete(queueKeys[i])
+			continue
+		}
+		var pych Paychan
+		k.cdc.MustUnmarshalBinary(bz, &pych)
+
+		// closingReceiverAmount is cumulative; only the part not yet withdrawn
+		// is still in balance
+		owed := pych.closingReceiverAmount.Minus(pych.withdrawn)
+		k.coinKeeper.AddCoins(ctx, pych.receiver, owed)
+		k.coinKeeper.AddCoins(ctx, pych.sender, pych.balance.Minus(owed))
+
+		store.Delete(pychKey)
+		store.Delete(queueKeys[i])
+
+		tags = tags.AppendTags(sdk.NewTags(
+			"action", []byte("timeout_close"),
+			"sender", pych.sender.Bytes(),
+			"receiver", pych.receiver.Bytes(),
+		))
+	}
+	return tags
+}
+
+// TopUpPaychan adds funds to an existing channel without closing it.
+func (k Keeper) TopUpPaychan(ctx sdk.Context, sender sdk.Address, receiver sdk.Address, id int64, amount sdk.Coins) (sdk.Tags, sdk.Error) {
+	if !amount.IsValid() || !amount.IsPositive() {
+		return nil, sdk.ErrInvalidCoins(amount.String())
+	}
+	pych, exists := k.GetPaychan(ctx, sender, receiver, id)
+	if !exists {
+		return nil, ErrPaychanNotFound(k.codespace)
+	}
+	if pych.IsClosing() {
+		return nil, ErrPaychanClosing(k.codespace)
+	}
+
+	_, tags, err := k.coinKeeper.SubtractCoins(ctx, sender, amount)
+	if err != nil {
+		return nil, err
+	}
+	pych.balance = pych.balance.Plus(amount)
+	k.setPaychan(ctx, pych)
+
+	return tags, nil
+}
+
+// WithdrawPaychan lets the receiver pull part of a channel's balance against
+// the latest signed Update they hold, without closing the channel. su.Update.ReceiverAmount
+// is the cumulative amount owed to the receiver so far (as with a close
+// Update); only the increase over what's already been withdrawn is paid out.
+func (k Keeper) WithdrawPaychan(ctx sdk.Context, su SignedUpdate) (sdk.Tags, sdk.Error) {
+	if !su.Verify(k.cdc) {
+		return nil, ErrUnauthorizedCloser(k.codespace)
+	}
+	pych, exists := k.GetPaychan(ctx, su.Sender, su.Receiver, su.ID)
+	if !exists {
+		return nil, ErrPaychanNotFound(k.codespace)
+	}
+	if pych.IsClosing() {
+		return nil, ErrPaychanClosing(k.codespace)
+	}
+	if su.Sequence <= pych.sequence {
+		return nil, ErrSequenceTooLow(k.codespace)
+	}
+	if !su.ReceiverAmount.IsValid() || !su.ReceiverAmount.IsGTE(pych.withdrawn) || su.ReceiverAmount.IsEqual(pych.withdrawn) {
+		return nil, sdk.ErrInvalidCoins("update does not exceed what has already been withdrawn")
+	}
+	delta := su.ReceiverAmount.Minus(pych.withdrawn)
+	if !pych.balance.Minus(delta).IsNotNegative() {
+		return nil, ErrReceiverAmountExceedsBalance(k.codespace)
+	}
+
+	pych.balance = pych.balance.Minus(delta)
+	pych.withdrawn = su.ReceiverAmount
+	pych.sequence = su.Sequence
+	k.setPaychan(ctx, pych)
+
+	k.coinKeeper.AddCoins(ctx, pych.receiver, delta)
+
+	tags := sdk.NewTags(
+		"action", []byte("withdraw"),
+		"sender", pych.sender.Bytes(),
+		"receiver", pych.receiver.Bytes(),
+	)
+	return tags, nil
+}
+
+// SenderTimeoutClose lets a sender reclaim a channel's remaining balance once
+// its ExpiryHeight has passed, protecting against a receiver who never shows
+// up to submit a close.
+func (k Keeper) SenderTimeoutClose(ctx sdk.Context, sender sdk.Address, receiver sdk.Address, id int64) (sdk.Tags, sdk.Error) {
+	pych, exists := k.GetPaychan(ctx, sender, receiver, id)
+	if !exists {
+		return nil, ErrPaychanNotFound(k.codespace)
+	}
+	if pych.expiryHeight == 0 || ctx.BlockHeight() < pych.expiryHeight {
+		return nil, sdk.ErrUnauthorized("channel has no expiry, or it has not yet passed")
+	}
+	if pych.IsClosing() {
+		return nil, ErrPaychanClosing(k.codespace)
+	}
+	// As with ClosePaychan, an HTLC's amount is carved out of balance, not
+	// tracked anywhere else; deleting the channel here would destroy it
+	// instead of refunding or paying it out. Make the caller resolve them
+	// first.
+	if len(pych.htlcs) > 0 {
+		return nil, sdk.ErrUnauthorized("cannot close a channel with pending htlcs")
+	}
+
+	store := ctx.KVStore(k.storeKey)
+	k.coinKeeper.AddCoins(ctx, pych.sender, pych.balance)
+	store.Delete(paychanKey(pych.sender, pych.receiver, pych.id))
+
+	tags := sdk.NewTags(
+		"action", []byte("sender_timeout_close"),
+		"sender", pych.sender.Bytes(),
+		"receiver", pych.receiver.Bytes(),
+	)
+	return tags, nil
+}
+
+// ApplyStreamClaim folds an Interledger STREAM claim into a channel's
+// balance, crediting the receiver for the increase in claim.AmountDelta over
+// what's already been withdrawn, without closing the channel. It mirrors
+// WithdrawPaychan — claim.AmountDelta is cumulative since the channel opened,
+// same as an Update's ReceiverAmount, so only the increase actually needs to
+// be paid out — but additionally requires the claim's hashlock to be opened,
+// so a connector can gate settlement on proof an ILP packet was actually
+// delivered.
+func (k Keeper) ApplyStreamClaim(ctx sdk.Context, claim SignedStreamClaim) (sdk.Tags, sdk.Error) {
+	if !claim.Verify(k.cdc) {
+		return nil, ErrUnauthorizedCloser(k.codespace)
+	}
+	if sha256.Sum256(claim.Fulfillment) != claim.Condition {
+		return nil, ErrHTLCPreimageMismatch(k.codespace)
+	}
+	pych, exists := k.GetPaychan(ctx, claim.Sender, claim.Receiver, claim.ID)
+	if !exists {
+		return nil, ErrPaychanNotFound(k.codespace)
+	}
+	if pych.IsClosing() {
+		return nil, ErrPaychanClosing(k.codespace)
+	}
+	if claim.Sequence <= pych.sequence {
+		return nil, ErrSequenceTooLow(k.codespace)
+	}
+	if !claim.AmountDelta.IsValid() || !claim.AmountDelta.IsGTE(pych.withdrawn) || claim.AmountDelta.IsEqual(pych.withdrawn) {
+		return nil, sdk.ErrInvalidCoins("claim does not exceed what has already been withdrawn")
+	}
+	delta := claim.AmountDelta.Minus(pych.withdrawn)
+	if !pych.balance.Minus(delta).IsNotNegative() {
+		return nil, ErrReceiverAmountExceedsBalance(k.codespace)
+	}
+
+	pych.balance = pych.balance.Minus(delta)
+	pych.withdrawn = claim.AmountDelta
+	pych.sequence = claim.Sequence
+	k.setPaychan(ctx, pych)
+
+	k.coinKeeper.AddCoins(ctx, pych.receiver, delta)
+
+	tags := sdk.NewTags(
+		"action", []byte("apply_stream_claim"),
+		"sender", pych.sender.Bytes(),
+		"receiver", pych.receiver.Bytes(),
+	)
+	return tags, nil
+}
+
+// GetBidirectionalPaychan retrieves a bidirectional payment channel struct.
+func (k Keeper) GetBidirectionalPaychan(ctx sdk.Context, partyA sdk.Address, partyB sdk.Address, id int64) (BidirectionalPaychan, bool) {
+	var pych BidirectionalPaychan
+	store := ctx.KVStore(k.storeKey)
+	bz := store.Get(bidirectionalPaychanKey(partyA, partyB, id))
+	if bz == nil {
+		return pych, false
+	}
+	k.cdc.MustUnmarshalBinary(bz, &pych)
+	return pych, true
+}
+
+func (k Keeper) setBidirectionalPaychan(ctx sdk.Context, pych BidirectionalPaychan) {
+	store := ctx.KVStore(k.storeKey)
+	bz := k.cdc.MustMarshalBinary(pych)
+	store.Set(bidirectionalPaychanKey(pych.partyA, pych.partyB, pych.id), bz)
+}
+
+// CreateBidirectionalPaychan opens a channel funded by both parties, either
+// of whom may end up owing the other.
+func (k Keeper) CreateBidirectionalPaychan(ctx sdk.Context, partyA sdk.Address, partyB sdk.Address, amountA sdk.Coins, amountB sdk.Coins) (sdk.Tags, sdk.Error) {
+	if len(partyA) == 0 {
+		return nil, sdk.ErrInvalidAddress(partyA.String())
+	}
+	if len(partyB) == 0 {
+		return nil, sdk.ErrInvalidAddress(partyB.String())
+	}
+	if !amountA.IsValid() || !amountB.IsValid() {
+		return nil, sdk.ErrInvalidCoins(amountA.Plus(amountB).String())
+	}
+
+	id := k.getAndIncrementNextBidirectionalPaychanID(ctx, partyA, partyB)
+
+	tags := sdk.NewTags()
+	if amountA.IsPositive() {
+		_, t, err := k.coinKeeper.SubtractCoins(ctx, partyA, amountA)
+		if err != nil {
+			return nil, err
+		}
+		tags = tags.AppendTags(t)
+	}
+	if amountB.IsPositive() {
+		_, t, err := k.coinKeeper.SubtractCoins(ctx, partyB, amountB)
+		if err != nil {
+			return nil, err
+		}
+		tags = tags.AppendTags(t)
+	}
+
+	pych := BidirectionalPaychan{
+		partyA:   partyA,
+		partyB:   partyB,
+		id:       id,
+		balanceA: amountA,
+		balanceB: amountB,
+	}
+	k.setBidirectionalPaychan(ctx, pych)
+
+	return tags, nil
+}
+
+// CloseBidirectionalPaychan settles a bidirectional channel according to a
+// balance split both parties have co-signed, and deletes it.
+func (k Keeper) CloseBidirectionalPaychan(ctx sdk.Context, su SignedBidirectionalUpdate) (sdk.Tags, sdk.Error) {
+	if !su.Verify(k.cdc) {
+		return nil, ErrUnauthorizedCloser(k.codespace)
+	}
+	pych, exists := k.GetBidirectionalPaychan(ctx, su.PartyA, su.PartyB, su.ID)
+	if !exists {
+		return nil, ErrPaychanNotFound(k.codespace)
+	}
+	if su.Sequence <= pych.sequence {
+		return nil, ErrSequenceTooLow(k.codespace)
+	}
+	total := pych.balanceA.Plus(pych.balanceB)
+	if !su.BalanceA.Plus(su.BalanceB).IsEqual(total) {
+		return nil, sdk.ErrInvalidCoins("split must add up to the channel's total balance")
+	}
+	if !su.BalanceA.IsNotNegative() || !su.BalanceB.IsNotNegative() {
+		return nil, sdk.ErrInvalidCoins("split must not be negative")
+	}
+
+	k.coinKeeper.AddCoins(ctx, pych.partyA, su.BalanceA)
+	k.coinKeeper.AddCoins(ctx, pych.partyB, su.BalanceB)
+
+	store := ctx.KVStore(k.storeKey)
+	store.Delete(bidirectionalPaychanKey(pych.partyA, pych.partyB, pych.id))
+
+	tags := sdk.NewTags(
+		"action", []byte("close_bidirectional"),
+		"partyA", pych.partyA.Bytes(),
+		"partyB", pych.partyB.Bytes(),
+	)
+	return tags, nil
+}
@@ -0,0 +1,640 @@
+package paychan
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	abci "github.com/tendermint/abci/types"
+	crypto "github.com/tendermint/go-crypto"
+	dbm "github.com/tendermint/tmlibs/db"
+	"github.com/tendermint/tmlibs/log"
+
+	sdk "github.com/cosmos/cosmos-sdk/store"
+	sdkTypes "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/wire"
+	"github.com/cosmos/cosmos-sdk/x/auth"
+	"github.com/cosmos/cosmos-sdk/x/bank"
+
+	"github.com/stretchr/testify/require"
+)
+
+func createTestInput(t *testing.T) (sdkTypes.Context, Keeper) {
+	db := dbm.NewMemDB()
+	authKey := sdkTypes.NewKVStoreKey("auth")
+	paychanKey := sdkTypes.NewKVStoreKey("paychan")
+
+	ms := sdk.NewCommitMultiStore(db)
+	ms.MountStoreWithDB(authKey, sdkTypes.StoreTypeIAVL, db)
+	ms.MountStoreWithDB(paychanKey, sdkTypes.StoreTypeIAVL, db)
+	require.Nil(t, ms.LoadLatestVersion())
+
+	ctx := sdkTypes.NewContext(ms, abci.Header{}, false, log.NewNopLogger())
+	cdc := wire.NewCodec()
+
+	accountMapper := auth.NewAccountMapper(cdc, authKey, auth.ProtoBaseAccount)
+	ck := bank.NewKeeper(accountMapper)
+	keeper := NewKeeper(cdc, paychanKey, ck, DefaultDisputeBlocks, CodespacePaychan)
+
+	return ctx, keeper
+}
+
+// signedUpdate builds a valid SignedUpdate for the given sender key.
+func signedUpdate(cdc *wire.Codec, senderKey crypto.PrivKey, receiver sdkTypes.Address, id int64, receiverAmount sdkTypes.Coins, sequence int64) SignedUpdate {
+	u := Update{
+		Sender:         sdkTypes.Address(senderKey.PubKey().Address()),
+		Receiver:       receiver,
+		ID:             id,
+		ReceiverAmount: receiverAmount,
+		Sequence:       sequence,
+	}
+	sig, err := senderKey.Sign(cdc.MustMarshalBinary(u))
+	if err != nil {
+		panic(err)
+	}
+	return SignedUpdate{Update: u, SenderPubKey: senderKey.PubKey(), Signature: sig}
+}
+
+// streamClaim builds a valid, fulfilled SignedStreamClaim hash-locked to
+// preimage.
+func streamClaim(cdc *wire.Codec, senderKey crypto.PrivKey, receiver sdkTypes.Address, id int64, amountDelta sdkTypes.Coins, sequence int64, preimage []byte) SignedStreamClaim {
+	condition := sha256.Sum256(preimage)
+	claim := StreamClaim{
+		Sender:      sdkTypes.Address(senderKey.PubKey().Address()),
+		Receiver:    receiver,
+		ID:          id,
+		AmountDelta: amountDelta,
+		Sequence:    sequence,
+		Condition:   condition,
+		Fulfillment: preimage,
+	}
+	signed, err := SignStreamClaim(cdc, senderKey, claim)
+	if err != nil {
+		panic(err)
+	}
+	return signed
+}
+
+func TestKeeper_ApplyStreamClaim_CreditsReceiverWithoutClosing(t *testing.T) {
+	ctx, k := createTestInput(t)
+
+	senderKey := crypto.GenPrivKeyEd25519()
+	sender := sdkTypes.Address(senderKey.PubKey().Address())
+	receiver := sdkTypes.Address(crypto.GenPrivKeyEd25519().PubKey().Address())
+	amount := sdkTypes.Coins{{Denom: "KVA", Amount: 100}}
+
+	k.coinKeeper.AddCoins(ctx, sender, amount)
+	_, err := k.CreatePaychan(ctx, sender, receiver, amount, 0)
+	require.Nil(t, err)
+
+	claim := streamClaim(k.cdc, senderKey, receiver, 1, sdkTypes.Coins{{Denom: "KVA", Amount: 30}}, 1, []byte("preimage"))
+	_, err = k.ApplyStreamClaim(ctx, claim)
+	require.Nil(t, err)
+
+	pych, found := k.GetPaychan(ctx, sender, receiver, 1)
+	require.True(t, found)
+	require.Equal(t, sdkTypes.Coins{{Denom: "KVA", Amount: 70}}, pych.balance)
+	require.Equal(t, sdkTypes.Coins{{Denom: "KVA", Amount: 30}}, k.coinKeeper.GetCoins(ctx, receiver))
+}
+
+func TestKeeper_ApplyStreamClaim_WrongFulfillmentRejected(t *testing.T) {
+	ctx, k := createTestInput(t)
+
+	senderKey := crypto.GenPrivKeyEd25519()
+	sender := sdkTypes.Address(senderKey.PubKey().Address())
+	receiver := sdkTypes.Address(crypto.GenPrivKeyEd25519().PubKey().Address())
+	amount := sdkTypes.Coins{{Denom: "KVA", Amount: 100}}
+
+	k.coinKeeper.AddCoins(ctx, sender, amount)
+	_, err := k.CreatePaychan(ctx, sender, receiver, amount, 0)
+	require.Nil(t, err)
+
+	claim := streamClaim(k.cdc, senderKey, receiver, 1, sdkTypes.Coins{{Denom: "KVA", Amount: 30}}, 1, []byte("preimage"))
+	claim.Fulfillment = []byte("wrong preimage")
+	_, err = k.ApplyStreamClaim(ctx, claim)
+	require.NotNil(t, err)
+
+	_, found := k.GetPaychan(ctx, sender, receiver, 1)
+	require.True(t, found)
+	require.Equal(t, sdkTypes.Coins(nil), k.coinKeeper.GetCoins(ctx, receiver))
+}
+
+func TestKeeper_ApplyStreamClaim_OnlyFinalClaimLandsOnChain(t *testing.T) {
+	ctx, k := createTestInput(t)
+
+	senderKey := crypto.GenPrivKeyEd25519()
+	sender := sdkTypes.Address(senderKey.PubKey().Address())
+	receiver := sdkTypes.Address(crypto.GenPrivKeyEd25519().PubKey().Address())
+	amount := sdkTypes.Coins{{Denom: "KVA", Amount: 10000}}
+
+	k.coinKeeper.AddCoins(ctx, sender, amount)
+	_, err := k.CreatePaychan(ctx, sender, receiver, amount, 0)
+	require.Nil(t, err)
+
+	// a connector exchanges 10k claims entirely off-chain, each superseding
+	// the last with a higher cumulative AmountDelta; only the very last one
+	// is ever applied to the keeper.
+	var last SignedStreamClaim
+	for i := int64(1); i <= 10000; i++ {
+		last = streamClaim(k.cdc, senderKey, receiver, 1, sdkTypes.Coins{{Denom: "KVA", Amount: i}}, i, []byte("preimage"))
+	}
+
+	_, err = k.ApplyStreamClaim(ctx, last)
+	require.Nil(t, err)
+
+	pych, found := k.GetPaychan(ctx, sender, receiver, 1)
+	require.True(t, found)
+	require.True(t, pych.balance.IsNotNegative())
+	require.Equal(t, sdkTypes.Coins{{Denom: "KVA", Amount: 10000}}, k.coinKeeper.GetCoins(ctx, receiver))
+	require.Equal(t, int64(10000), pych.sequence)
+}
+
+func TestKeeper_ApplyStreamClaim_SecondSettlementOnlyPaysTheIncrease(t *testing.T) {
+	ctx, k := createTestInput(t)
+
+	senderKey := crypto.GenPrivKeyEd25519()
+	sender := sdkTypes.Address(senderKey.PubKey().Address())
+	receiver := sdkTypes.Address(crypto.GenPrivKeyEd25519().PubKey().Address())
+	amount := sdkTypes.Coins{{Denom: "KVA", Amount: 100}}
+
+	k.coinKeeper.AddCoins(ctx, sender, amount)
+	_, err := k.CreatePaychan(ctx, sender, receiver, amount, 0)
+	require.Nil(t, err)
+
+	first := streamClaim(k.cdc, senderKey, receiver, 1, sdkTypes.Coins{{Denom: "KVA", Amount: 30}}, 1, []byte("preimage"))
+	_, err = k.ApplyStreamClaim(ctx, first)
+	require.Nil(t, err)
+
+	// a later on-chain settlement still carries AmountDelta cumulative since
+	// the channel opened, not since the last settlement; only the increase
+	// over what's already been withdrawn (30) should move this time.
+	second := streamClaim(k.cdc, senderKey, receiver, 1, sdkTypes.Coins{{Denom: "KVA", Amount: 50}}, 2, []byte("preimage"))
+	_, err = k.ApplyStreamClaim(ctx, second)
+	require.Nil(t, err)
+
+	pych, found := k.GetPaychan(ctx, sender, receiver, 1)
+	require.True(t, found)
+	require.Equal(t, sdkTypes.Coins{{Denom: "KVA", Amount: 50}}, pych.balance)
+	require.Equal(t, sdkTypes.Coins{{Denom: "KVA", Amount: 50}}, k.coinKeeper.GetCoins(ctx, receiver))
+}
+
+func TestKeeper_FulfillHTLC_PaysBeneficiaryOnCorrectPreimage(t *testing.T) {
+	ctx, k := createTestInput(t)
+
+	sender := sdkTypes.Address(crypto.GenPrivKeyEd25519().PubKey().Address())
+	receiver := sdkTypes.Address(crypto.GenPrivKeyEd25519().PubKey().Address())
+	beneficiary := sdkTypes.Address(crypto.GenPrivKeyEd25519().PubKey().Address())
+	amount := sdkTypes.Coins{{Denom: "KVA", Amount: 100}}
+	preimage := []byte("the secret")
+	hashlock := sha256.Sum256(preimage)
+
+	k.coinKeeper.AddCoins(ctx, sender, amount)
+	_, err := k.CreatePaychan(ctx, sender, receiver, amount, 0)
+	require.Nil(t, err)
+
+	_, err = k.AddHTLC(ctx, sender, receiver, 1, sdkTypes.Coins{{Denom: "KVA", Amount: 40}}, hashlock, ctx.BlockHeight()+10, beneficiary)
+	require.Nil(t, err)
+
+	pych, found := k.GetPaychan(ctx, sender, receiver, 1)
+	require.True(t, found)
+	require.Equal(t, sdkTypes.Coins{{Denom: "KVA", Amount: 60}}, pych.balance)
+	require.Equal(t, 1, len(pych.htlcs))
+
+	_, err = k.FulfillHTLC(ctx, sender, receiver, 1, pych.htlcs[0].ID, beneficiary, preimage)
+	require.Nil(t, err)
+
+	pych, found = k.GetPaychan(ctx, sender, receiver, 1)
+	require.True(t, found)
+	require.Equal(t, 0, len(pych.htlcs))
+	require.Equal(t, sdkTypes.Coins{{Denom: "KVA", Amount: 40}}, k.coinKeeper.GetCoins(ctx, beneficiary))
+}
+
+func TestKeeper_FulfillHTLC_ThirdPartyBeneficiaryDoesNotCountAgainstReceiverWithdrawn(t *testing.T) {
+	ctx, k := createTestInput(t)
+
+	senderKey := crypto.GenPrivKeyEd25519()
+	sender := sdkTypes.Address(senderKey.PubKey().Address())
+	receiver := sdkTypes.Address(crypto.GenPrivKeyEd25519().PubKey().Address())
+	beneficiary := sdkTypes.Address(crypto.GenPrivKeyEd25519().PubKey().Address())
+	amount := sdkTypes.Coins{{Denom: "KVA", Amount: 100}}
+	preimage := []byte("the secret")
+	hashlock := sha256.Sum256(preimage)
+
+	k.coinKeeper.AddCoins(ctx, sender, amount)
+	_, err := k.CreatePaychan(ctx, sender, receiver, amount, 0)
+	require.Nil(t, err)
+
+	_, err = k.AddHTLC(ctx, sender, receiver, 1, sdkTypes.Coins{{Denom: "KVA", Amount: 40}}, hashlock, ctx.BlockHeight()+10, beneficiary)
+	require.Nil(t, err)
+
+	pych, found := k.GetPaychan(ctx, sender, receiver, 1)
+	require.True(t, found)
+	_, err = k.FulfillHTLC(ctx, sender, receiver, 1, pych.htlcs[0].ID, beneficiary, preimage)
+	require.Nil(t, err)
+
+	// the fulfilled HTLC routed to a third party, not the receiver; a later
+	// Update authorizing the receiver's full remaining share (60, the
+	// balance left after the HTLC was carved out) must pay out all of it,
+	// not have the HTLC's 40 wrongly subtracted as if the receiver had
+	// already withdrawn it.
+	su := signedUpdate(k.cdc, senderKey, receiver, 1, sdkTypes.Coins{{Denom: "KVA", Amount: 60}}, 1)
+	_, err = k.WithdrawPaychan(ctx, su)
+	require.Nil(t, err)
+
+	require.Equal(t, sdkTypes.Coins{{Denom: "KVA", Amount: 60}}, k.coinKeeper.GetCoins(ctx, receiver))
+}
+
+func TestKeeper_FulfillHTLC_WrongPreimageRejected(t *testing.T) {
+	ctx, k := createTestInput(t)
+
+	sender := sdkTypes.Address(crypto.GenPrivKeyEd25519().PubKey().Address())
+	receiver := sdkTypes.Address(crypto.GenPrivKeyEd25519().PubKey().Address())
+	beneficiary := sdkTypes.Address(crypto.GenPrivKeyEd25519().PubKey().Address())
+	amount := sdkTypes.Coins{{Denom: "KVA", Amount: 100}}
+	hashlock := sha256.Sum256([]byte("the secret"))
+
+	k.coinKeeper.AddCoins(ctx, sender, amount)
+	_, err := k.CreatePaychan(ctx, sender, receiver, amount, 0)
+	require.Nil(t, err)
+
+	_, err = k.AddHTLC(ctx, sender, receiver, 1, sdkTypes.Coins{{Denom: "KVA", Amount: 40}}, hashlock, ctx.BlockHeight()+10, beneficiary)
+	require.Nil(t, err)
+	pych, _ := k.GetPaychan(ctx, sender, receiver, 1)
+
+	_, err = k.FulfillHTLC(ctx, sender, receiver, 1, pych.htlcs[0].ID, beneficiary, []byte("wrong secret"))
+	require.NotNil(t, err)
+}
+
+func TestKeeper_CancelHTLC_RefundsAfterTimeout(t *testing.T) {
+	ctx, k := createTestInput(t)
+
+	sender := sdkTypes.Address(crypto.GenPrivKeyEd25519().PubKey().Address())
+	receiver := sdkTypes.Address(crypto.GenPrivKeyEd25519().PubKey().Address())
+	beneficiary := sdkTypes.Address(crypto.GenPrivKeyEd25519().PubKey().Address())
+	amount := sdkTypes.Coins{{Denom: "KVA", Amount: 100}}
+	hashlock := sha256.Sum256([]byte("the secret"))
+
+	k.coinKeeper.AddCoins(ctx, sender, amount)
+	_, err := k.CreatePaychan(ctx, sender, receiver, amount, 0)
+	require.Nil(t, err)
+
+	_, err = k.AddHTLC(ctx, sender, receiver, 1, sdkTypes.Coins{{Denom: "KVA", Amount: 40}}, hashlock, ctx.BlockHeight()+10, beneficiary)
+	require.Nil(t, err)
+	pych, _ := k.GetPaychan(ctx, sender, receiver, 1)
+	htlcID := pych.htlcs[0].ID
+
+	_, err = k.CancelHTLC(ctx, sender, receiver, 1, htlcID)
+	require.NotNil(t, err, "should not cancel before timeout")
+
+	expiredCtx := ctx.WithBlockHeight(ctx.BlockHeight() + 10)
+	_, err = k.CancelHTLC(expiredCtx, sender, receiver, 1, htlcID)
+	require.Nil(t, err)
+
+	pych, found := k.GetPaychan(expiredCtx, sender, receiver, 1)
+	require.True(t, found)
+	require.Equal(t, 0, len(pych.htlcs))
+	require.Equal(t, amount, pych.balance)
+}
+
+func TestKeeper_EndBlocker_SweepsExpiredHTLC(t *testing.T) {
+	ctx, k := createTestInput(t)
+
+	sender := sdkTypes.Address(crypto.GenPrivKeyEd25519().PubKey().Address())
+	receiver := sdkTypes.Address(crypto.GenPrivKeyEd25519().PubKey().Address())
+	beneficiary := sdkTypes.Address(crypto.GenPrivKeyEd25519().PubKey().Address())
+	amount := sdkTypes.Coins{{Denom: "KVA", Amount: 100}}
+	hashlock := sha256.Sum256([]byte("the secret"))
+
+	k.coinKeeper.AddCoins(ctx, sender, amount)
+	_, err := k.CreatePaychan(ctx, sender, receiver, amount, 0)
+	require.Nil(t, err)
+
+	timeoutHeight := ctx.BlockHeight() + 10
+	_, err = k.AddHTLC(ctx, sender, receiver, 1, sdkTypes.Coins{{Denom: "KVA", Amount: 40}}, hashlock, timeoutHeight, beneficiary)
+	require.Nil(t, err)
+
+	expiredCtx := ctx.WithBlockHeight(timeoutHeight)
+	EndBlocker(expiredCtx, k)
+
+	pych, found := k.GetPaychan(expiredCtx, sender, receiver, 1)
+	require.True(t, found)
+	require.Equal(t, 0, len(pych.htlcs))
+	require.Equal(t, amount, pych.balance)
+}
+
+func TestKeeper_ClosePaychan_RefusesWithPendingHTLC(t *testing.T) {
+	ctx, k := createTestInput(t)
+
+	sender := sdkTypes.Address(crypto.GenPrivKeyEd25519().PubKey().Address())
+	receiver := sdkTypes.Address(crypto.GenPrivKeyEd25519().PubKey().Address())
+	beneficiary := sdkTypes.Address(crypto.GenPrivKeyEd25519().PubKey().Address())
+	amount := sdkTypes.Coins{{Denom: "KVA", Amount: 100}}
+	hashlock := sha256.Sum256([]byte("the secret"))
+
+	k.coinKeeper.AddCoins(ctx, sender, amount)
+	_, err := k.CreatePaychan(ctx, sender, receiver, amount, 0)
+	require.Nil(t, err)
+
+	_, err = k.AddHTLC(ctx, sender, receiver, 1, sdkTypes.Coins{{Denom: "KVA", Amount: 40}}, hashlock, ctx.BlockHeight()+10, beneficiary)
+	require.Nil(t, err)
+
+	_, err = k.ClosePaychan(ctx, sender, receiver, 1, sdkTypes.Coins{{Denom: "KVA", Amount: 10}})
+	require.NotNil(t, err)
+}
+
+func TestKeeper_SubmitUpdate_RefusesWithPendingHTLC(t *testing.T) {
+	ctx, k := createTestInput(t)
+
+	senderKey := crypto.GenPrivKeyEd25519()
+	sender := sdkTypes.Address(senderKey.PubKey().Address())
+	receiver := sdkTypes.Address(crypto.GenPrivKeyEd25519().PubKey().Address())
+	beneficiary := sdkTypes.Address(crypto.GenPrivKeyEd25519().PubKey().Address())
+	amount := sdkTypes.Coins{{Denom: "KVA", Amount: 100}}
+	hashlock := sha256.Sum256([]byte("the secret"))
+
+	k.coinKeeper.AddCoins(ctx, sender, amount)
+	_, err := k.CreatePaychan(ctx, sender, receiver, amount, 0)
+	require.Nil(t, err)
+
+	_, err = k.AddHTLC(ctx, sender, receiver, 1, sdkTypes.Coins{{Denom: "KVA", Amount: 40}}, hashlock, ctx.BlockHeight()+10, beneficiary)
+	require.Nil(t, err)
+
+	su := signedUpdate(k.cdc, senderKey, receiver, 1, sdkTypes.Coins{{Denom: "KVA", Amount: 10}}, 1)
+	_, err = k.SubmitUpdate(ctx, su)
+	require.NotNil(t, err)
+}
+
+func TestKeeper_SubmitUpdate_HigherSequenceReplaces(t *testing.T) {
+	ctx, k := createTestInput(t)
+
+	senderKey := crypto.GenPrivKeyEd25519()
+	sender := sdkTypes.Address(senderKey.PubKey().Address())
+	receiver := sdkTypes.Address(crypto.GenPrivKeyEd25519().PubKey().Address())
+	amount := sdkTypes.Coins{{Denom: "KVA", Amount: 100}}
+
+	k.coinKeeper.AddCoins(ctx, sender, amount)
+	_, err := k.CreatePaychan(ctx, sender, receiver, amount, 0)
+	require.Nil(t, err)
+
+	su1 := signedUpdate(k.cdc, senderKey, receiver, 1, sdkTypes.Coins{{Denom: "KVA", Amount: 10}}, 1)
+	_, err = k.SubmitUpdate(ctx, su1)
+	require.Nil(t, err)
+
+	su2 := signedUpdate(k.cdc, senderKey, receiver, 1, sdkTypes.Coins{{Denom: "KVA", Amount: 40}}, 2)
+	_, err = k.SubmitUpdate(ctx, su2)
+	require.Nil(t, err)
+
+	pych, found := k.GetPaychan(ctx, sender, receiver, 1)
+	require.True(t, found)
+	require.Equal(t, int64(2), pych.sequence)
+	require.Equal(t, sdkTypes.Coins{{Denom: "KVA", Amount: 40}}, pych.closingReceiverAmount)
+}
+
+func TestKeeper_SubmitUpdate_StaleSequenceRejected(t *testing.T) {
+	ctx, k := createTestInput(t)
+
+	senderKey := crypto.GenPrivKeyEd25519()
+	sender := sdkTypes.Address(senderKey.PubKey().Address())
+	receiver := sdkTypes.Address(crypto.GenPrivKeyEd25519().PubKey().Address())
+	amount := sdkTypes.Coins{{Denom: "KVA", Amount: 100}}
+
+	k.coinKeeper.AddCoins(ctx, sender, amount)
+	_, err := k.CreatePaychan(ctx, sender, receiver, amount, 0)
+	require.Nil(t, err)
+
+	su2 := signedUpdate(k.cdc, senderKey, receiver, 1, sdkTypes.Coins{{Denom: "KVA", Amount: 40}}, 2)
+	_, err = k.SubmitUpdate(ctx, su2)
+	require.Nil(t, err)
+
+	stale := signedUpdate(k.cdc, senderKey, receiver, 1, sdkTypes.Coins{{Denom: "KVA", Amount: 20}}, 2)
+	_, err = k.SubmitUpdate(ctx, stale)
+	require.NotNil(t, err)
+}
+
+func TestKeeper_EndBlocker_SettlesAfterDisputeWindow(t *testing.T) {
+	ctx, k := createTestInput(t)
+
+	senderKey := crypto.GenPrivKeyEd25519()
+	sender := sdkTypes.Address(senderKey.PubKey().Address())
+	receiver := sdkTypes.Address(crypto.GenPrivKeyEd25519().PubKey().Address())
+	amount := sdkTypes.Coins{{Denom: "KVA", Amount: 100}}
+
+	k.coinKeeper.AddCoins(ctx, sender, amount)
+	_, err := k.CreatePaychan(ctx, sender, receiver, amount, 0)
+	require.Nil(t, err)
+
+	su := signedUpdate(k.cdc, senderKey, receiver, 1, sdkTypes.Coins{{Denom: "KVA", Amount: 30}}, 1)
+	_, err = k.SubmitUpdate(ctx, su)
+	require.Nil(t, err)
+
+	matureCtx := ctx.WithBlockHeight(ctx.BlockHeight() + k.disputeBlocks)
+	EndBlocker(matureCtx, k)
+
+	_, found := k.GetPaychan(matureCtx, sender, receiver, 1)
+	require.False(t, found)
+
+	receiverCoins := k.coinKeeper.GetCoins(matureCtx, receiver)
+	require.Equal(t, sdkTypes.Coins{{Denom: "KVA", Amount: 30}}, receiverCoins)
+	senderCoins := k.coinKeeper.GetCoins(matureCtx, sender)
+	require.Equal(t, sdkTypes.Coins{{Denom: "KVA", Amount: 70}}, senderCoins)
+}
+
+func TestKeeper_ClosePaychan_ShortCircuitsDisputeQueue(t *testing.T) {
+	ctx, k := createTestInput(t)
+	// closingHeight 0 means "not closing", so the close must start at a real
+	// block height
+	ctx = ctx.WithBlockHeight(1)
+
+	senderKey := crypto.GenPrivKeyEd25519()
+	sender := sdkTypes.Address(senderKey.PubKey().Address())
+	receiver := sdkTypes.Address(crypto.GenPrivKeyEd25519().PubKey().Address())
+	amount := sdkTypes.Coins{{Denom: "KVA", Amount: 100}}
+
+	k.coinKeeper.AddCoins(ctx, sender, amount)
+	_, err := k.CreatePaychan(ctx, sender, receiver, amount, 0)
+	require.Nil(t, err)
+
+	su := signedUpdate(k.cdc, senderKey, receiver, 1, sdkTypes.Coins{{Denom: "KVA", Amount: 30}}, 1)
+	_, err = k.SubmitUpdate(ctx, su)
+	require.Nil(t, err)
+
+	_, err = k.ClosePaychan(ctx, sender, receiver, 1, sdkTypes.Coins{{Denom: "KVA", Amount: 30}})
+	require.Nil(t, err)
+
+	// the cooperative close must have removed the channel's dispute queue
+	// entry, and EndBlocker at the original maturity height has nothing to do.
+	queueKey := closingQueueKey(ctx.BlockHeight()+k.disputeBlocks, paychanKey(sender, receiver, 1))
+	require.Nil(t, ctx.KVStore(k.storeKey).Get(queueKey))
+	matureCtx := ctx.WithBlockHeight(ctx.BlockHeight() + k.disputeBlocks)
+	tags := EndBlocker(matureCtx, k)
+	require.Equal(t, 0, len(tags))
+}
+
+func TestKeeper_TopUpPaychan(t *testing.T) {
+	ctx, k := createTestInput(t)
+
+	sender := sdkTypes.Address(crypto.GenPrivKeyEd25519().PubKey().Address())
+	receiver := sdkTypes.Address(crypto.GenPrivKeyEd25519().PubKey().Address())
+	amount := sdkTypes.Coins{{Denom: "KVA", Amount: 100}}
+
+	k.coinKeeper.AddCoins(ctx, sender, amount.Plus(amount))
+	_, err := k.CreatePaychan(ctx, sender, receiver, amount, 0)
+	require.Nil(t, err)
+
+	_, err = k.TopUpPaychan(ctx, sender, receiver, 1, amount)
+	require.Nil(t, err)
+
+	pych, found := k.GetPaychan(ctx, sender, receiver, 1)
+	require.True(t, found)
+	require.Equal(t, sdkTypes.Coins{{Denom: "KVA", Amount: 200}}, pych.balance)
+}
+
+func TestKeeper_WithdrawPaychan_PartialWithoutClosing(t *testing.T) {
+	ctx, k := createTestInput(t)
+
+	senderKey := crypto.GenPrivKeyEd25519()
+	sender := sdkTypes.Address(senderKey.PubKey().Address())
+	receiver := sdkTypes.Address(crypto.GenPrivKeyEd25519().PubKey().Address())
+	amount := sdkTypes.Coins{{Denom: "KVA", Amount: 100}}
+
+	k.coinKeeper.AddCoins(ctx, sender, amount)
+	_, err := k.CreatePaychan(ctx, sender, receiver, amount, 0)
+	require.Nil(t, err)
+
+	su1 := signedUpdate(k.cdc, senderKey, receiver, 1, sdkTypes.Coins{{Denom: "KVA", Amount: 20}}, 1)
+	_, err = k.WithdrawPaychan(ctx, su1)
+	require.Nil(t, err)
+
+	pych, found := k.GetPaychan(ctx, sender, receiver, 1)
+	require.True(t, found)
+	require.Equal(t, sdkTypes.Coins{{Denom: "KVA", Amount: 80}}, pych.balance)
+	require.Equal(t, sdkTypes.Coins{{Denom: "KVA", Amount: 20}}, k.coinKeeper.GetCoins(ctx, receiver))
+
+	// a later update with a higher cumulative amount only pays out the delta
+	su2 := signedUpdate(k.cdc, senderKey, receiver, 1, sdkTypes.Coins{{Denom: "KVA", Amount: 50}}, 2)
+	_, err = k.WithdrawPaychan(ctx, su2)
+	require.Nil(t, err)
+
+	pych, found = k.GetPaychan(ctx, sender, receiver, 1)
+	require.True(t, found)
+	require.Equal(t, sdkTypes.Coins{{Denom: "KVA", Amount: 50}}, pych.balance)
+	require.Equal(t, sdkTypes.Coins{{Denom: "KVA", Amount: 50}}, k.coinKeeper.GetCoins(ctx, receiver))
+}
+
+func TestKeeper_SenderTimeoutClose(t *testing.T) {
+	ctx, k := createTestInput(t)
+
+	sender := sdkTypes.Address(crypto.GenPrivKeyEd25519().PubKey().Address())
+	receiver := sdkTypes.Address(crypto.GenPrivKeyEd25519().PubKey().Address())
+	amount := sdkTypes.Coins{{Denom: "KVA", Amount: 100}}
+
+	k.coinKeeper.AddCoins(ctx, sender, amount)
+	_, err := k.CreatePaychan(ctx, sender, receiver, amount, 10)
+	require.Nil(t, err)
+
+	_, err = k.SenderTimeoutClose(ctx, sender, receiver, 1)
+	require.NotNil(t, err, "expiry height has not passed yet")
+
+	expiredCtx := ctx.WithBlockHeight(10)
+	_, err = k.SenderTimeoutClose(expiredCtx, sender, receiver, 1)
+	require.Nil(t, err)
+
+	_, found := k.GetPaychan(expiredCtx, sender, receiver, 1)
+	require.False(t, found)
+	require.Equal(t, amount, k.coinKeeper.GetCoins(expiredCtx, sender))
+}
+
+func TestKeeper_SenderTimeoutClose_RefusesWithPendingHTLC(t *testing.T) {
+	ctx, k := createTestInput(t)
+
+	sender := sdkTypes.Address(crypto.GenPrivKeyEd25519().PubKey().Address())
+	receiver := sdkTypes.Address(crypto.GenPrivKeyEd25519().PubKey().Address())
+	beneficiary := sdkTypes.Address(crypto.GenPrivKeyEd25519().PubKey().Address())
+	amount := sdkTypes.Coins{{Denom: "KVA", Amount: 100}}
+	hashlock := sha256.Sum256([]byte("the secret"))
+
+	k.coinKeeper.AddCoins(ctx, sender, amount)
+	_, err := k.CreatePaychan(ctx, sender, receiver, amount, 10)
+	require.Nil(t, err)
+
+	_, err = k.AddHTLC(ctx, sender, receiver, 1, sdkTypes.Coins{{Denom: "KVA", Amount: 40}}, hashlock, 20, beneficiary)
+	require.Nil(t, err)
+
+	expiredCtx := ctx.WithBlockHeight(10)
+	_, err = k.SenderTimeoutClose(expiredCtx, sender, receiver, 1)
+	require.NotNil(t, err)
+
+	_, found := k.GetPaychan(expiredCtx, sender, receiver, 1)
+	require.True(t, found)
+}
+
+func TestKeeper_CloseBidirectionalPaychan(t *testing.T) {
+	ctx, k := createTestInput(t)
+
+	partyAKey := crypto.GenPrivKeyEd25519()
+	partyBKey := crypto.GenPrivKeyEd25519()
+	partyA := sdkTypes.Address(partyAKey.PubKey().Address())
+	partyB := sdkTypes.Address(partyBKey.PubKey().Address())
+	amountA := sdkTypes.Coins{{Denom: "KVA", Amount: 60}}
+	amountB := sdkTypes.Coins{{Denom: "KVA", Amount: 40}}
+
+	k.coinKeeper.AddCoins(ctx, partyA, amountA)
+	k.coinKeeper.AddCoins(ctx, partyB, amountB)
+	_, err := k.CreateBidirectionalPaychan(ctx, partyA, partyB, amountA, amountB)
+	require.Nil(t, err)
+
+	u := BidirectionalUpdate{
+		PartyA:   partyA,
+		PartyB:   partyB,
+		ID:       1,
+		BalanceA: sdkTypes.Coins{{Denom: "KVA", Amount: 10}},
+		BalanceB: sdkTypes.Coins{{Denom: "KVA", Amount: 90}},
+		Sequence: 1,
+	}
+	signBytes := k.cdc.MustMarshalBinary(u)
+	sigA, err := partyAKey.Sign(signBytes)
+	require.Nil(t, err)
+	sigB, err := partyBKey.Sign(signBytes)
+	require.Nil(t, err)
+	su := SignedBidirectionalUpdate{
+		BidirectionalUpdate: u,
+		PartyAPubKey:        partyAKey.PubKey(),
+		PartyBPubKey:        partyBKey.PubKey(),
+		SignatureA:          sigA,
+		SignatureB:          sigB,
+	}
+
+	_, err = k.CloseBidirectionalPaychan(ctx, su)
+	require.Nil(t, err)
+
+	_, found := k.GetBidirectionalPaychan(ctx, partyA, partyB, 1)
+	require.False(t, found)
+	require.Equal(t, sdkTypes.Coins{{Denom: "KVA", Amount: 10}}, k.coinKeeper.GetCoins(ctx, partyA))
+	require.Equal(t, sdkTypes.Coins{{Denom: "KVA", Amount: 90}}, k.coinKeeper.GetCoins(ctx, partyB))
+}
+
+func TestKeeper_CreatePaychan_IDsDontCollideOrReuse(t *testing.T) {
+	ctx, k := createTestInput(t)
+
+	sender := sdkTypes.Address(crypto.GenPrivKeyEd25519().PubKey().Address())
+	receiver := sdkTypes.Address(crypto.GenPrivKeyEd25519().PubKey().Address())
+	amount := sdkTypes.Coins{{Denom: "KVA", Amount: 10}}
+
+	k.coinKeeper.AddCoins(ctx, sender, amount.Plus(amount).Plus(amount))
+
+	_, err := k.CreatePaychan(ctx, sender, receiver, amount, 0)
+	require.Nil(t, err)
+	_, err = k.CreatePaychan(ctx, sender, receiver, amount, 0)
+	require.Nil(t, err)
+
+	paychans := k.GetPaychans(ctx, sender, receiver)
+	require.Equal(t, 2, len(paychans))
+
+	_, err = k.ClosePaychan(ctx, sender, receiver, 2, amount)
+	require.Nil(t, err)
+
+	_, err = k.CreatePaychan(ctx, sender, receiver, amount, 0)
+	require.Nil(t, err)
+
+	_, found := k.GetPaychan(ctx, sender, receiver, 3)
+	require.True(t, found, "the id of a deleted channel should never be reused")
+}
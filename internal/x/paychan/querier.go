@@ -0,0 +1,159 @@
+package paychan
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+
+	abci "github.com/tendermint/abci/types"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/wire"
+)
+
+// Query endpoints supported by the paychan querier, reachable under
+// custom/paychan/<endpoint>/...:
+//
+//	QueryPaychan:          .../{sender}/{receiver}/{id}
+//	QueryPaychansBySender: .../{sender}
+//	QueryPaychansByPair:   .../{sender}/{receiver}
+const (
+	QueryPaychan          = "paychan"
+	QueryPaychansBySender = "paychansBySender"
+	QueryPaychansByPair   = "paychansByPair"
+)
+
+// NewQuerier returns a function handling all paychan queries, to be wired up
+// under the module's query route in the app.
+func NewQuerier(k Keeper) sdk.Querier {
+	return func(ctx sdk.Context, path []string, req abci.RequestQuery) ([]byte, sdk.Error) {
+		switch path[0] {
+		case QueryPaychan:
+			return queryPaychan(ctx, path[1:], k)
+		case QueryPaychansBySender:
+			return queryPaychansBySender(ctx, path[1:], k)
+		case QueryPaychansByPair:
+			return queryPaychansByPair(ctx, path[1:], k)
+		default:
+			return nil, sdk.ErrUnknownRequest(fmt.Sprintf("unknown paychan query endpoint: %s", path[0]))
+		}
+	}
+}
+
+func queryPaychan(ctx sdk.Context, path []string, k Keeper) ([]byte, sdk.Error) {
+	if len(path) != 3 {
+		return nil, sdk.ErrUnknownRequest("expected path sender/receiver/id")
+	}
+	sender, receiver, err := parseAddressPair(path[0], path[1])
+	if err != nil {
+		return nil, err
+	}
+	id, err := parseID(path[2])
+	if err != nil {
+		return nil, err
+	}
+
+	pych, exists := k.GetPaychan(ctx, sender, receiver, id)
+	if !exists {
+		return nil, ErrPaychanNotFound(k.codespace)
+	}
+	return marshalQueryResult(k.cdc, newPaychanOutput(pych))
+}
+
+func queryPaychansBySender(ctx sdk.Context, path []string, k Keeper) ([]byte, sdk.Error) {
+	if len(path) != 1 {
+		return nil, sdk.ErrUnknownRequest("expected path sender")
+	}
+	sender, err := parseAddress(path[0])
+	if err != nil {
+		return nil, err
+	}
+	paychans := k.GetAllPaychansFrom(ctx, sender)
+	return marshalQueryResult(k.cdc, newPaychanOutputs(paychans))
+}
+
+func queryPaychansByPair(ctx sdk.Context, path []string, k Keeper) ([]byte, sdk.Error) {
+	if len(path) != 2 {
+		return nil, sdk.ErrUnknownRequest("expected path sender/receiver")
+	}
+	sender, receiver, err := parseAddressPair(path[0], path[1])
+	if err != nil {
+		return nil, err
+	}
+	paychans := k.GetPaychans(ctx, sender, receiver)
+	return marshalQueryResult(k.cdc, newPaychanOutputs(paychans))
+}
+
+// paychanOutput is the JSON shape returned to querier/REST/CLI callers. It
+// exists because Paychan itself keeps its fields private.
+type paychanOutput struct {
+	Sender                sdk.Address
+	Receiver              sdk.Address
+	ID                    int64
+	Balance               sdk.Coins
+	Withdrawn             sdk.Coins
+	Sequence              int64
+	ExpiryHeight          int64
+	Closing               bool
+	ClosingReceiverAmount sdk.Coins
+	HTLCs                 []PendingHTLC
+}
+
+func newPaychanOutput(pych Paychan) paychanOutput {
+	return paychanOutput{
+		Sender:                pych.sender,
+		Receiver:              pych.receiver,
+		ID:                    pych.id,
+		Balance:               pych.balance,
+		Withdrawn:             pych.withdrawn,
+		Sequence:              pych.sequence,
+		ExpiryHeight:          pych.expiryHeight,
+		Closing:               pych.IsClosing(),
+		ClosingReceiverAmount: pych.closingReceiverAmount,
+		HTLCs:                 pych.htlcs,
+	}
+}
+
+func newPaychanOutputs(paychans []Paychan) []paychanOutput {
+	outputs := make([]paychanOutput, len(paychans))
+	for i, pych := range paychans {
+		outputs[i] = newPaychanOutput(pych)
+	}
+	return outputs
+}
+
+func parseAddress(raw string) (sdk.Address, sdk.Error) {
+	bz, err := hex.DecodeString(raw)
+	if err != nil {
+		return nil, sdk.ErrInvalidAddress(raw)
+	}
+	return sdk.Address(bz), nil
+}
+
+func parseAddressPair(rawSender, rawReceiver string) (sdk.Address, sdk.Address, sdk.Error) {
+	sender, err := parseAddress(rawSender)
+	if err != nil {
+		return nil, nil, err
+	}
+	receiver, err := parseAddress(rawReceiver)
+	if err != nil {
+		return nil, nil, err
+	}
+	return sender, receiver, nil
+}
+
+func parseID(raw string) (int64, sdk.Error) {
+	id, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return 0, sdk.ErrUnknownRequest("id must be an integer")
+	}
+	return id, nil
+}
+
+func marshalQueryResult(cdc *wire.Codec, v interface{}) ([]byte, sdk.Error) {
+	bz, err := cdc.MarshalJSON(v)
+	if err != nil {
+		return nil, sdk.ErrInternal(err.Error())
+	}
+	return bz, nil
+}
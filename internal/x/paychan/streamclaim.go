@@ -0,0 +1,104 @@
+package paychan
+
+import (
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/wire"
+	crypto "github.com/tendermint/go-crypto"
+)
+
+// StreamClaim is an Interledger STREAM-inspired claim: a sender-signed,
+// hash-locked authorization to move funds from a channel's
+// balance to its receiver, without closing the channel. AmountDelta is the
+// cumulative amount authorized since the channel opened (like Update.ReceiverAmount),
+// so that, like an Update, only the latest claim a receiver holds ever needs
+// to be applied on-chain — it already subsumes every smaller increment that
+// came before it off-chain, including ones already settled.
+//
+// Condition/Fulfillment let a claim double as the settlement leg of an ILP
+// packet: a connector only proves Fulfillment once whatever the packet was
+// paying for has actually been delivered.
+type StreamClaim struct {
+	Sender      sdk.Address
+	Receiver    sdk.Address
+	ID          int64
+	AmountDelta sdk.Coins
+	Sequence    int64
+	Condition   [32]byte
+	Fulfillment []byte
+}
+
+// SignedStreamClaim pairs a StreamClaim with the sender's signature over it.
+type SignedStreamClaim struct {
+	StreamClaim
+	SenderPubKey crypto.PubKey
+	Signature    crypto.Signature
+}
+
+// Verify checks that SenderPubKey is really the channel sender's key, and
+// that Signature is valid over the StreamClaim with its Fulfillment left out,
+// since the fulfillment is attached after the sender signs. It does not check
+// the hashlock; callers that have a Fulfillment to check should also verify
+// sha256(Fulfillment) == Condition.
+func (sc SignedStreamClaim) Verify(cdc *wire.Codec) bool {
+	if !sdk.Address(sc.SenderPubKey.Address()).Equals(sc.Sender) {
+		return false
+	}
+	unsigned := sc.StreamClaim
+	unsigned.Fulfillment = nil
+	signBytes := cdc.MustMarshalBinary(unsigned)
+	return sc.SenderPubKey.VerifyBytes(signBytes, sc.Signature)
+}
+
+// SignStreamClaim signs a StreamClaim with the sender's key, for off-chain
+// tooling (e.g. the interledger package) that builds claims outside the
+// keeper. The signature does not cover Fulfillment.
+func SignStreamClaim(cdc *wire.Codec, senderKey crypto.PrivKey, claim StreamClaim) (SignedStreamClaim, error) {
+	unsigned := claim
+	unsigned.Fulfillment = nil
+	sig, err := senderKey.Sign(cdc.MustMarshalBinary(unsigned))
+	if err != nil {
+		return SignedStreamClaim{}, err
+	}
+	return SignedStreamClaim{StreamClaim: claim, SenderPubKey: senderKey.PubKey(), Signature: sig}, nil
+}
+
+// MsgApplyStreamClaim posts a StreamClaim on-chain, crediting the receiver
+// and shrinking the channel's balance without closing it. Only the receiver
+// needs to sign the transaction; the sender's authorization comes from the
+// SignedStreamClaim itself.
+type MsgApplyStreamClaim struct {
+	Claim SignedStreamClaim
+}
+
+// Type implements sdk.Msg.
+func (msg MsgApplyStreamClaim) Type() string { return "paychan" }
+
+// GetSignBytes implements sdk.Msg.
+func (msg MsgApplyStreamClaim) GetSignBytes() []byte {
+	return sdk.MustSortJSON(msgCdc.MustMarshalJSON(msg))
+}
+
+// GetSigners implements sdk.Msg.
+func (msg MsgApplyStreamClaim) GetSigners() []sdk.Address {
+	return []sdk.Address{msg.Claim.Receiver}
+}
+
+// ValidateBasic implements sdk.Msg.
+func (msg MsgApplyStreamClaim) ValidateBasic() sdk.Error {
+	if len(msg.Claim.Sender) == 0 {
+		return sdk.ErrInvalidAddress(msg.Claim.Sender.String())
+	}
+	if len(msg.Claim.Receiver) == 0 {
+		return sdk.ErrInvalidAddress(msg.Claim.Receiver.String())
+	}
+	if !msg.Claim.AmountDelta.IsValid() || !msg.Claim.AmountDelta.IsPositive() {
+		return sdk.ErrInvalidCoins(msg.Claim.AmountDelta.String())
+	}
+	if msg.Claim.Sequence <= 0 {
+		return sdk.ErrUnknownRequest("sequence must be positive")
+	}
+	if len(msg.Claim.Fulfillment) == 0 {
+		return sdk.ErrUnknownRequest("fulfillment is required")
+	}
+	return nil
+}
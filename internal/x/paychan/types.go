@@ -0,0 +1,573 @@
+package paychan
+
+import (
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/wire"
+	crypto "github.com/tendermint/go-crypto"
+)
+
+// Paychan is a single payment channel between a sender and a receiver.
+//
+// The sender locks up balance when the channel is created. While the channel
+// is open the two parties exchange signed Updates off-chain; only one of
+// them ever needs to be submitted on-chain, to start a unilateral close.
+type Paychan struct {
+	sender   sdk.Address
+	receiver sdk.Address
+	id       int64
+	balance  sdk.Coins
+
+	// withdrawn is the cumulative amount the receiver has already pulled out
+	// via WithdrawPaychan, ApplyStreamClaim, or a fulfilled HTLC whose
+	// beneficiary is the receiver, without closing the channel.
+	withdrawn sdk.Coins
+
+	// sequence is the highest Update or StreamClaim sequence number acted on
+	// so far, by SubmitUpdate, WithdrawPaychan, or ApplyStreamClaim. They all
+	// share it so a replayed or stale update can never be accepted by any of
+	// these paths.
+	sequence int64
+
+	// expiryHeight, if non-zero, lets sender reclaim the channel's remaining
+	// balance via SenderTimeoutClose once the chain passes this height,
+	// protecting against a receiver who never closes.
+	expiryHeight int64
+
+	// Set once a close has been initiated by SubmitUpdate, until the channel
+	// settles or is cooperatively closed. closingHeight == 0 means the
+	// channel is open and not disputing a close.
+	closingHeight         int64
+	closingReceiverAmount sdk.Coins
+
+	// htlcs are this channel's live Hashed-Timelock conditional payments.
+	// Their combined Amount is already deducted from balance, so
+	// balance+sum(htlc amounts) is always the channel's true locked total.
+	htlcs []PendingHTLC
+
+	// nextHTLCID hands out ids for this channel's HTLCs, scoped to the
+	// channel itself rather than the (sender, receiver) pair.
+	nextHTLCID int64
+}
+
+// Sender returns the channel's funding party.
+func (pych Paychan) Sender() sdk.Address { return pych.sender }
+
+// Receiver returns the channel's payee.
+func (pych Paychan) Receiver() sdk.Address { return pych.receiver }
+
+// ID returns the channel's id, unique per (sender, receiver) pair.
+func (pych Paychan) ID() int64 { return pych.id }
+
+// Balance returns the coins currently locked in the channel.
+func (pych Paychan) Balance() sdk.Coins { return pych.balance }
+
+// IsClosing reports whether a unilateral close is in progress.
+func (pych Paychan) IsClosing() bool { return pych.closingHeight != 0 }
+
+// Withdrawn returns the cumulative amount the receiver has withdrawn without
+// closing the channel.
+func (pych Paychan) Withdrawn() sdk.Coins { return pych.withdrawn }
+
+// Sequence returns the highest Update sequence number acted on so far.
+func (pych Paychan) Sequence() int64 { return pych.sequence }
+
+// HTLCs returns the channel's live Hashed-Timelock conditional payments.
+func (pych Paychan) HTLCs() []PendingHTLC { return pych.htlcs }
+
+// PendingHTLC is a conditional payment carved out of a channel's balance: it
+// pays Amount to Beneficiary if they reveal, before TimeoutHeight, a preimage
+// hashing to Hashlock; otherwise it can be cancelled after TimeoutHeight,
+// which puts Amount back into the channel's balance. It lets a paychan fund one leg of a cross-chain atomic
+// swap, mirroring the hashlock on the other chain.
+type PendingHTLC struct {
+	ID            int64
+	Amount        sdk.Coins
+	Hashlock      [32]byte
+	TimeoutHeight int64
+	Beneficiary   sdk.Address
+}
+
+// Update is the off-chain payload a sender signs and hands to the receiver.
+// The receiver can submit the latest one they hold to close the channel.
+type Update struct {
+	Sender         sdk.Address
+	Receiver       sdk.Address
+	ID             int64
+	ReceiverAmount sdk.Coins
+	Sequence       int64
+}
+
+// SignedUpdate pairs an Update with the sender's signature over it, plus the
+// pubkey needed to check that signature (the store only has the address).
+type SignedUpdate struct {
+	Update
+	SenderPubKey crypto.PubKey
+	Signature    crypto.Signature
+}
+
+// Verify checks that SenderPubKey really is the channel sender's key, and
+// that Signature is a valid signature by that key over the Update.
+func (su SignedUpdate) Verify(cdc *wire.Codec) bool {
+	if !sdk.Address(su.SenderPubKey.Address()).Equals(su.Sender) {
+		return false
+	}
+	signBytes := cdc.MustMarshalBinary(su.Update)
+	return su.SenderPubKey.VerifyBytes(signBytes, su.Signature)
+}
+
+// BidirectionalPaychan is a channel where both parties lock up funds and
+// either one can end up owing the other, unlike the sender-funds-only
+// Paychan. It's stored under its own key prefix.
+type BidirectionalPaychan struct {
+	partyA sdk.Address
+	partyB sdk.Address
+	id     int64
+
+	balanceA sdk.Coins
+	balanceB sdk.Coins
+	sequence int64
+}
+
+// PartyA returns the channel's initiating party.
+func (pych BidirectionalPaychan) PartyA() sdk.Address { return pych.partyA }
+
+// PartyB returns the channel's other party.
+func (pych BidirectionalPaychan) PartyB() sdk.Address { return pych.partyB }
+
+// ID returns the channel's id, unique per (partyA, partyB) pair.
+func (pych BidirectionalPaychan) ID() int64 { return pych.id }
+
+// BidirectionalUpdate is the off-chain payload both parties of a
+// BidirectionalPaychan co-sign to redistribute its balance.
+type BidirectionalUpdate struct {
+	PartyA   sdk.Address
+	PartyB   sdk.Address
+	ID       int64
+	BalanceA sdk.Coins
+	BalanceB sdk.Coins
+	Sequence int64
+}
+
+// SignedBidirectionalUpdate pairs a BidirectionalUpdate with both parties'
+// signatures over it. Unlike the unidirectional Update, both sides must sign
+// since either can end up the net debtor.
+type SignedBidirectionalUpdate struct {
+	BidirectionalUpdate
+	PartyAPubKey crypto.PubKey
+	PartyBPubKey crypto.PubKey
+	SignatureA   crypto.Signature
+	SignatureB   crypto.Signature
+}
+
+// Verify checks that the pubkeys match the channel's parties, and that both
+// signatures are valid over the BidirectionalUpdate.
+func (su SignedBidirectionalUpdate) Verify(cdc *wire.Codec) bool {
+	if !sdk.Address(su.PartyAPubKey.Address()).Equals(su.PartyA) {
+		return false
+	}
+	if !sdk.Address(su.PartyBPubKey.Address()).Equals(su.PartyB) {
+		return false
+	}
+	signBytes := cdc.MustMarshalBinary(su.BidirectionalUpdate)
+	return su.PartyAPubKey.VerifyBytes(signBytes, su.SignatureA) &&
+		su.PartyBPubKey.VerifyBytes(signBytes, su.SignatureB)
+}
+
+// MsgCreatePaychan creates a new channel, locking sender funds into it.
+// ExpiryHeight, if non-zero, lets the sender reclaim the channel via
+// SenderTimeoutClose once the chain passes that height, in case the
+// receiver never shows up to close it.
+type MsgCreatePaychan struct {
+	Sender       sdk.Address
+	Receiver     sdk.Address
+	Amount       sdk.Coins
+	ExpiryHeight int64
+}
+
+// Type implements sdk.Msg.
+func (msg MsgCreatePaychan) Type() string { return "paychan" }
+
+// GetSignBytes implements sdk.Msg.
+func (msg MsgCreatePaychan) GetSignBytes() []byte {
+	return sdk.MustSortJSON(msgCdc.MustMarshalJSON(msg))
+}
+
+// GetSigners implements sdk.Msg. Only the sender needs to sign: they are the
+// one locking up funds.
+func (msg MsgCreatePaychan) GetSigners() []sdk.Address { return []sdk.Address{msg.Sender} }
+
+// ValidateBasic implements sdk.Msg.
+func (msg MsgCreatePaychan) ValidateBasic() sdk.Error {
+	if len(msg.Sender) == 0 {
+		return sdk.ErrInvalidAddress(msg.Sender.String())
+	}
+	if len(msg.Receiver) == 0 {
+		return sdk.ErrInvalidAddress(msg.Receiver.String())
+	}
+	if !msg.Amount.IsValid() || !msg.Amount.IsPositive() {
+		return sdk.ErrInvalidCoins(msg.Amount.String())
+	}
+	return nil
+}
+
+// MsgSubmitUpdate starts (or advances) a unilateral close by posting a
+// sender-signed Update the receiver holds. Only the receiver needs to sign
+// the transaction; the sender's authorization comes from SignedUpdate itself.
+type MsgSubmitUpdate struct {
+	Update SignedUpdate
+}
+
+// Type implements sdk.Msg.
+func (msg MsgSubmitUpdate) Type() string { return "paychan" }
+
+// GetSignBytes implements sdk.Msg.
+func (msg MsgSubmitUpdate) GetSignBytes() []byte {
+	return sdk.MustSortJSON(msgCdc.MustMarshalJSON(msg))
+}
+
+// GetSigners implements sdk.Msg.
+func (msg MsgSubmitUpdate) GetSigners() []sdk.Address {
+	return []sdk.Address{msg.Update.Receiver}
+}
+
+// ValidateBasic implements sdk.Msg.
+func (msg MsgSubmitUpdate) ValidateBasic() sdk.Error {
+	if len(msg.Update.Sender) == 0 {
+		return sdk.ErrInvalidAddress(msg.Update.Sender.String())
+	}
+	if len(msg.Update.Receiver) == 0 {
+		return sdk.ErrInvalidAddress(msg.Update.Receiver.String())
+	}
+	if msg.Update.ID < 0 {
+		return ErrInvalidPaychanID(CodespacePaychan, msg.Update.ID)
+	}
+	if !msg.Update.ReceiverAmount.IsValid() || !msg.Update.ReceiverAmount.IsPositive() {
+		return sdk.ErrInvalidCoins(msg.Update.ReceiverAmount.String())
+	}
+	if msg.Update.Sequence <= 0 {
+		return sdk.ErrUnknownRequest("sequence must be positive")
+	}
+	return nil
+}
+
+// MsgClosePaychan is the existing cooperative close: both parties agree on
+// the split and the channel settles immediately, with no dispute window.
+type MsgClosePaychan struct {
+	Sender         sdk.Address
+	Receiver       sdk.Address
+	ID             int64
+	ReceiverAmount sdk.Coins
+}
+
+// Type implements sdk.Msg.
+func (msg MsgClosePaychan) Type() string { return "paychan" }
+
+// GetSignBytes implements sdk.Msg.
+func (msg MsgClosePaychan) GetSignBytes() []byte {
+	return sdk.MustSortJSON(msgCdc.MustMarshalJSON(msg))
+}
+
+// GetSigners implements sdk.Msg. Both parties must sign since this settles
+// immediately instead of going through the dispute window.
+func (msg MsgClosePaychan) GetSigners() []sdk.Address {
+	return []sdk.Address{msg.Sender, msg.Receiver}
+}
+
+// ValidateBasic implements sdk.Msg.
+func (msg MsgClosePaychan) ValidateBasic() sdk.Error {
+	if len(msg.Sender) == 0 {
+		return sdk.ErrInvalidAddress(msg.Sender.String())
+	}
+	if len(msg.Receiver) == 0 {
+		return sdk.ErrInvalidAddress(msg.Receiver.String())
+	}
+	if msg.ID < 0 {
+		return ErrInvalidPaychanID(CodespacePaychan, msg.ID)
+	}
+	if !msg.ReceiverAmount.IsValid() || !msg.ReceiverAmount.IsPositive() {
+		return sdk.ErrInvalidCoins(msg.ReceiverAmount.String())
+	}
+	return nil
+}
+
+// MsgTopUp adds funds to an existing channel without closing it.
+type MsgTopUp struct {
+	Sender   sdk.Address
+	Receiver sdk.Address
+	ID       int64
+	Amount   sdk.Coins
+}
+
+// Type implements sdk.Msg.
+func (msg MsgTopUp) Type() string { return "paychan" }
+
+// GetSignBytes implements sdk.Msg.
+func (msg MsgTopUp) GetSignBytes() []byte { return sdk.MustSortJSON(msgCdc.MustMarshalJSON(msg)) }
+
+// GetSigners implements sdk.Msg.
+func (msg MsgTopUp) GetSigners() []sdk.Address { return []sdk.Address{msg.Sender} }
+
+// ValidateBasic implements sdk.Msg.
+func (msg MsgTopUp) ValidateBasic() sdk.Error {
+	if len(msg.Sender) == 0 {
+		return sdk.ErrInvalidAddress(msg.Sender.String())
+	}
+	if len(msg.Receiver) == 0 {
+		return sdk.ErrInvalidAddress(msg.Receiver.String())
+	}
+	if msg.ID < 0 {
+		return ErrInvalidPaychanID(CodespacePaychan, msg.ID)
+	}
+	if !msg.Amount.IsValid() || !msg.Amount.IsPositive() {
+		return sdk.ErrInvalidCoins(msg.Amount.String())
+	}
+	return nil
+}
+
+// MsgWithdraw lets a receiver pull part of a channel's balance against the
+// latest signed Update they hold, without closing the channel.
+type MsgWithdraw struct {
+	Update SignedUpdate
+}
+
+// Type implements sdk.Msg.
+func (msg MsgWithdraw) Type() string { return "paychan" }
+
+// GetSignBytes implements sdk.Msg.
+func (msg MsgWithdraw) GetSignBytes() []byte { return sdk.MustSortJSON(msgCdc.MustMarshalJSON(msg)) }
+
+// GetSigners implements sdk.Msg.
+func (msg MsgWithdraw) GetSigners() []sdk.Address { return []sdk.Address{msg.Update.Receiver} }
+
+// ValidateBasic implements sdk.Msg.
+func (msg MsgWithdraw) ValidateBasic() sdk.Error {
+	if len(msg.Update.Sender) == 0 {
+		return sdk.ErrInvalidAddress(msg.Update.Sender.String())
+	}
+	if len(msg.Update.Receiver) == 0 {
+		return sdk.ErrInvalidAddress(msg.Update.Receiver.String())
+	}
+	if !msg.Update.ReceiverAmount.IsValid() || !msg.Update.ReceiverAmount.IsPositive() {
+		return sdk.ErrInvalidCoins(msg.Update.ReceiverAmount.String())
+	}
+	if msg.Update.Sequence <= 0 {
+		return sdk.ErrUnknownRequest("sequence must be positive")
+	}
+	return nil
+}
+
+// MsgSenderTimeoutClose lets a sender reclaim a channel's remaining balance
+// once its ExpiryHeight has passed, in case the receiver disappeared.
+type MsgSenderTimeoutClose struct {
+	Sender   sdk.Address
+	Receiver sdk.Address
+	ID       int64
+}
+
+// Type implements sdk.Msg.
+func (msg MsgSenderTimeoutClose) Type() string { return "paychan" }
+
+// GetSignBytes implements sdk.Msg.
+func (msg MsgSenderTimeoutClose) GetSignBytes() []byte {
+	return sdk.MustSortJSON(msgCdc.MustMarshalJSON(msg))
+}
+
+// GetSigners implements sdk.Msg.
+func (msg MsgSenderTimeoutClose) GetSigners() []sdk.Address { return []sdk.Address{msg.Sender} }
+
+// ValidateBasic implements sdk.Msg.
+func (msg MsgSenderTimeoutClose) ValidateBasic() sdk.Error {
+	if len(msg.Sender) == 0 {
+		return sdk.ErrInvalidAddress(msg.Sender.String())
+	}
+	if len(msg.Receiver) == 0 {
+		return sdk.ErrInvalidAddress(msg.Receiver.String())
+	}
+	if msg.ID < 0 {
+		return ErrInvalidPaychanID(CodespacePaychan, msg.ID)
+	}
+	return nil
+}
+
+// MsgCreateBidirectionalPaychan creates a new bidirectional channel, locking
+// funds from both parties into it.
+type MsgCreateBidirectionalPaychan struct {
+	PartyA  sdk.Address
+	PartyB  sdk.Address
+	AmountA sdk.Coins
+	AmountB sdk.Coins
+}
+
+// Type implements sdk.Msg.
+func (msg MsgCreateBidirectionalPaychan) Type() string { return "paychan" }
+
+// GetSignBytes implements sdk.Msg.
+func (msg MsgCreateBidirectionalPaychan) GetSignBytes() []byte {
+	return sdk.MustSortJSON(msgCdc.MustMarshalJSON(msg))
+}
+
+// GetSigners implements sdk.Msg. Both parties lock up funds, so both sign.
+func (msg MsgCreateBidirectionalPaychan) GetSigners() []sdk.Address {
+	return []sdk.Address{msg.PartyA, msg.PartyB}
+}
+
+// ValidateBasic implements sdk.Msg.
+func (msg MsgCreateBidirectionalPaychan) ValidateBasic() sdk.Error {
+	if len(msg.PartyA) == 0 {
+		return sdk.ErrInvalidAddress(msg.PartyA.String())
+	}
+	if len(msg.PartyB) == 0 {
+		return sdk.ErrInvalidAddress(msg.PartyB.String())
+	}
+	if !msg.AmountA.IsValid() || !msg.AmountB.IsValid() {
+		return sdk.ErrInvalidCoins(msg.AmountA.Plus(msg.AmountB).String())
+	}
+	if !msg.AmountA.IsPositive() && !msg.AmountB.IsPositive() {
+		return sdk.ErrInvalidCoins("at least one party must fund the channel")
+	}
+	return nil
+}
+
+// MsgCloseBidirectionalPaychan settles a bidirectional channel according to
+// a balance split both parties have co-signed.
+type MsgCloseBidirectionalPaychan struct {
+	Update SignedBidirectionalUpdate
+}
+
+// Type implements sdk.Msg.
+func (msg MsgCloseBidirectionalPaychan) Type() string { return "paychan" }
+
+// GetSignBytes implements sdk.Msg.
+func (msg MsgCloseBidirectionalPaychan) GetSignBytes() []byte {
+	return sdk.MustSortJSON(msgCdc.MustMarshalJSON(msg))
+}
+
+// GetSigners implements sdk.Msg.
+func (msg MsgCloseBidirectionalPaychan) GetSigners() []sdk.Address {
+	return []sdk.Address{msg.Update.PartyA, msg.Update.PartyB}
+}
+
+// ValidateBasic implements sdk.Msg.
+func (msg MsgCloseBidirectionalPaychan) ValidateBasic() sdk.Error {
+	if len(msg.Update.PartyA) == 0 {
+		return sdk.ErrInvalidAddress(msg.Update.PartyA.String())
+	}
+	if len(msg.Update.PartyB) == 0 {
+		return sdk.ErrInvalidAddress(msg.Update.PartyB.String())
+	}
+	if !msg.Update.BalanceA.IsValid() || !msg.Update.BalanceB.IsValid() {
+		return sdk.ErrInvalidCoins(msg.Update.BalanceA.Plus(msg.Update.BalanceB).String())
+	}
+	return nil
+}
+
+// MsgAddHTLC carves a Hashed-Timelock conditional payment out of a channel's
+// balance, payable to Beneficiary once they reveal a preimage of Hashlock,
+// or refundable into the channel's balance once TimeoutHeight passes.
+type MsgAddHTLC struct {
+	Sender        sdk.Address
+	Receiver      sdk.Address
+	ID            int64
+	Amount        sdk.Coins
+	Hashlock      [32]byte
+	TimeoutHeight int64
+	Beneficiary   sdk.Address
+}
+
+// Type implements sdk.Msg.
+func (msg MsgAddHTLC) Type() string { return "paychan" }
+
+// GetSignBytes implements sdk.Msg.
+func (msg MsgAddHTLC) GetSignBytes() []byte { return sdk.MustSortJSON(msgCdc.MustMarshalJSON(msg)) }
+
+// GetSigners implements sdk.Msg. Only the sender needs to sign: they are the
+// one whose channel balance gets earmarked.
+func (msg MsgAddHTLC) GetSigners() []sdk.Address { return []sdk.Address{msg.Sender} }
+
+// ValidateBasic implements sdk.Msg.
+func (msg MsgAddHTLC) ValidateBasic() sdk.Error {
+	if len(msg.Sender) == 0 {
+		return sdk.ErrInvalidAddress(msg.Sender.String())
+	}
+	if len(msg.Receiver) == 0 {
+		return sdk.ErrInvalidAddress(msg.Receiver.String())
+	}
+	if len(msg.Beneficiary) == 0 {
+		return sdk.ErrInvalidAddress(msg.Beneficiary.String())
+	}
+	if !msg.Amount.IsValid() || !msg.Amount.IsPositive() {
+		return sdk.ErrInvalidCoins(msg.Amount.String())
+	}
+	if msg.TimeoutHeight <= 0 {
+		return sdk.ErrUnknownRequest("timeout height must be positive")
+	}
+	return nil
+}
+
+// MsgFulfillHTLC claims a pending HTLC's Amount for its Beneficiary by
+// revealing a preimage of its Hashlock, before it times out.
+type MsgFulfillHTLC struct {
+	Sender      sdk.Address
+	Receiver    sdk.Address
+	ID          int64
+	HTLCID      int64
+	Beneficiary sdk.Address
+	Preimage    []byte
+}
+
+// Type implements sdk.Msg.
+func (msg MsgFulfillHTLC) Type() string { return "paychan" }
+
+// GetSignBytes implements sdk.Msg.
+func (msg MsgFulfillHTLC) GetSignBytes() []byte {
+	return sdk.MustSortJSON(msgCdc.MustMarshalJSON(msg))
+}
+
+// GetSigners implements sdk.Msg. The beneficiary is the one claiming funds.
+func (msg MsgFulfillHTLC) GetSigners() []sdk.Address { return []sdk.Address{msg.Beneficiary} }
+
+// ValidateBasic implements sdk.Msg.
+func (msg MsgFulfillHTLC) ValidateBasic() sdk.Error {
+	if len(msg.Sender) == 0 {
+		return sdk.ErrInvalidAddress(msg.Sender.String())
+	}
+	if len(msg.Receiver) == 0 {
+		return sdk.ErrInvalidAddress(msg.Receiver.String())
+	}
+	if len(msg.Beneficiary) == 0 {
+		return sdk.ErrInvalidAddress(msg.Beneficiary.String())
+	}
+	if len(msg.Preimage) == 0 {
+		return sdk.ErrUnknownRequest("preimage is required")
+	}
+	return nil
+}
+
+// MsgCancelHTLC refunds a pending HTLC's Amount back into the channel's
+// balance once its TimeoutHeight has passed without being fulfilled.
+type MsgCancelHTLC struct {
+	Sender   sdk.Address
+	Receiver sdk.Address
+	ID       int64
+	HTLCID   int64
+}
+
+// Type implements sdk.Msg.
+func (msg MsgCancelHTLC) Type() string { return "paychan" }
+
+// GetSignBytes implements sdk.Msg.
+func (msg MsgCancelHTLC) GetSignBytes() []byte { return sdk.MustSortJSON(msgCdc.MustMarshalJSON(msg)) }
+
+// GetSigners implements sdk.Msg. The sender is the one reclaiming funds.
+func (msg MsgCancelHTLC) GetSigners() []sdk.Address { return []sdk.Address{msg.Sender} }
+
+// ValidateBasic implements sdk.Msg.
+func (msg MsgCancelHTLC) ValidateBasic() sdk.Error {
+	if len(msg.Sender) == 0 {
+		return sdk.ErrInvalidAddress(msg.Sender.String())
+	}
+	if len(msg.Receiver) == 0 {
+		return sdk.ErrInvalidAddress(msg.Receiver.String())
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package paychan
+
+import (
+	"github.com/cosmos/cosmos-sdk/wire"
+)
+
+// msgCdc is a package-local codec used only for GetSignBytes, so that Msg
+// types don't need the app's full codec to produce their sign bytes.
+var msgCdc = wire.NewCodec()
+
+// RegisterWire registers all paychan messages and types with the given codec.
+func RegisterWire(cdc *wire.Codec) {
+	cdc.RegisterConcrete(MsgCreatePaychan{}, "paychan/CreatePaychan", nil)
+	cdc.RegisterConcrete(MsgSubmitUpdate{}, "paychan/SubmitUpdate", nil)
+	cdc.RegisterConcrete(MsgClosePaychan{}, "paychan/ClosePaychan", nil)
+	cdc.RegisterConcrete(MsgTopUp{}, "paychan/TopUp", nil)
+	cdc.RegisterConcrete(MsgWithdraw{}, "paychan/Withdraw", nil)
+	cdc.RegisterConcrete(MsgSenderTimeoutClose{}, "paychan/SenderTimeoutClose", nil)
+	cdc.RegisterConcrete(MsgCreateBidirectionalPaychan{}, "paychan/CreateBidirectionalPaychan", nil)
+	cdc.RegisterConcrete(MsgCloseBidirectionalPaychan{}, "paychan/CloseBidirectionalPaychan", nil)
+	cdc.RegisterConcrete(MsgApplyStreamClaim{}, "paychan/ApplyStreamClaim", nil)
+	cdc.RegisterConcrete(MsgAddHTLC{}, "paychan/AddHTLC", nil)
+	cdc.RegisterConcrete(MsgFulfillHTLC{}, "paychan/FulfillHTLC", nil)
+	cdc.RegisterConcrete(MsgCancelHTLC{}, "paychan/CancelHTLC", nil)
+}
+
+func init() {
+	RegisterWire(msgCdc)
+}